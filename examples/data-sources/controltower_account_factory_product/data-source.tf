@@ -0,0 +1,5 @@
+data "controltower_account_factory_product" "product" {}
+
+output "launch_path_ids" {
+  value = data.controltower_account_factory_product.product.launch_paths[*].id
+}
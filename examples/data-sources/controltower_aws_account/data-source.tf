@@ -0,0 +1,3 @@
+data "controltower_aws_account" "account" {
+  email = "aws-admin@example.com"
+}
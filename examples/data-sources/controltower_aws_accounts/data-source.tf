@@ -0,0 +1,13 @@
+data "controltower_aws_accounts" "workloads" {
+  organizational_unit                 = "Workloads"
+  include_nested_organizational_units = true
+  statuses                            = ["AVAILABLE"]
+
+  tags = {
+    "team-name" = "platform"
+  }
+}
+
+output "account_ids" {
+  value = data.controltower_aws_accounts.workloads.account_ids
+}
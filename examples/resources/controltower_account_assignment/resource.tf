@@ -0,0 +1,13 @@
+resource "controltower_account_assignment" "platform_read_only" {
+  account_id          = controltower_aws_account.account.account_id
+  permission_set_name = "AWSReadOnlyAccess"
+  principal_type      = "GROUP"
+  principal_name      = "platform-admins"
+}
+
+resource "controltower_account_assignment" "team_power_user" {
+  account_id          = controltower_aws_account.account.account_id
+  permission_set_name = "AWSPowerUserAccess"
+  principal_type      = "USER"
+  principal_name      = "jane.doe@example.com"
+}
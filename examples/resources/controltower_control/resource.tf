@@ -0,0 +1,13 @@
+resource "controltower_control" "deny_regions" {
+  control_identifier = "arn:aws:controltower:eu-central-1::control/AWS-GR_REGION_DENY"
+  target_identifier  = controltower_organizational_unit.prod.arn
+
+  parameters {
+    key   = "AllowedRegions"
+    value = jsonencode(["eu-central-1", "eu-west-1"])
+  }
+
+  tags = {
+    "team-name" = "platform"
+  }
+}
@@ -0,0 +1,15 @@
+resource "controltower_enabled_baseline" "prod" {
+  baseline_identifier = "arn:aws:controltower:eu-central-1::baseline/17BSJV3IGJ2QSGA2"
+  baseline_version    = "4.0"
+  target_identifier   = controltower_organizational_unit.prod.arn
+  reset_on_drift      = true
+
+  parameters {
+    key   = "IdentityCenterEnabledBaselineArn"
+    value = jsonencode("arn:aws:controltower:eu-central-1:123456789012:enabledbaseline/XALULM96QHI525UOC")
+  }
+
+  tags = {
+    "team-name" = "platform"
+  }
+}
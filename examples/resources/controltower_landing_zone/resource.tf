@@ -0,0 +1,19 @@
+resource "controltower_landing_zone" "landing_zone" {
+  version          = "3.3"
+  governed_regions = ["eu-central-1", "eu-west-1"]
+
+  organization_structure {
+    security_ou_name = "Security"
+    sandbox_ou_name  = "Sandbox"
+  }
+
+  centralized_logging {
+    account_id                           = "111111111111"
+    logging_bucket_retention_days        = 365
+    access_logging_bucket_retention_days = 3650
+  }
+
+  security_roles {
+    account_id = "222222222222"
+  }
+}
@@ -0,0 +1,16 @@
+resource "controltower_organizational_unit" "prod" {
+  name        = "Prod"
+  parent_path = "Workloads"
+}
+
+resource "controltower_aws_account" "account" {
+  name                = "Example Account"
+  email               = "aws-admin@example.com"
+  organizational_unit = controltower_organizational_unit.prod.name
+
+  sso {
+    first_name = "John"
+    last_name  = "Doe"
+    email      = "john.doe@example.com"
+  }
+}
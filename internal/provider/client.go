@@ -0,0 +1,322 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/controltower"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/identitystore"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgTypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicecatalog"
+	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
+	ssoTypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// providerClient is the meta value handed to all resources. It holds the loaded
+// AWS configuration, the provider level settings, the shared service clients and
+// caches for lookups that do not change during a Terraform run.
+type providerClient struct {
+	config aws.Config
+
+	scconn            *servicecatalog.Client
+	organizationsconn *organizations.Client
+	ssoadminconn      *ssoadmin.Client
+	identitystoreconn *identitystore.Client
+	controltowerconn  *controltower.Client
+
+	// accountId is the ID of the account the provider is running in, if it was resolved.
+	accountId string
+
+	// endpoints maps a service name to a custom endpoint URL.
+	endpoints map[string]string
+
+	// defaultTags are added to the tags of every account.
+	defaultTags map[string]string
+
+	// ignoreTags are tags managed outside of Terraform.
+	ignoreTags *ignoreTagsConfig
+
+	// accountFactoryProduct is the default lookup of the Account Factory product.
+	accountFactoryProduct accountFactoryProduct
+
+	// ssoInstance is the default selection of the IAM Identity Center instance.
+	ssoInstance ssoInstance
+
+	// provisioningLock serialises Account Factory operations.
+	provisioningLock provisioningLock
+
+	// polling is the default polling behaviour for Account Factory operations.
+	polling pollingConfig
+
+	accountFactoryProducts     memo[accountFactoryProduct, accountFactoryProductIds]
+	ssoInstances               memo[string, *ssoadmin.ListInstancesOutput]
+	permissionSetArns          memo[permissionSetKey, string]
+	organizationalUnits        memo[string, *orgTypes.OrganizationalUnit]
+	organizationalUnitChildren memo[string, []orgTypes.OrganizationalUnit]
+	baselineArns               memo[string, map[string]string]
+}
+
+// connect creates the shared service clients. It must be called once the credentials are final.
+func (c *providerClient) connect() {
+	c.scconn = c.servicecatalogClient()
+	c.organizationsconn = c.organizationsClient()
+	c.ssoadminconn = c.ssoadminClient()
+	c.identitystoreconn = c.identitystoreClient()
+	c.controltowerconn = c.controltowerClient()
+}
+
+// endpoint returns the custom endpoint for the given service or nil if none was configured.
+func (c *providerClient) endpoint(service string) *string {
+	if v, ok := c.endpoints[service]; ok && v != "" {
+		return aws.String(v)
+	}
+
+	return nil
+}
+
+func (c *providerClient) servicecatalogClient() *servicecatalog.Client {
+	return servicecatalog.NewFromConfig(c.config, func(o *servicecatalog.Options) {
+		o.BaseEndpoint = c.endpoint("servicecatalog")
+	})
+}
+
+func (c *providerClient) organizationsClient() *organizations.Client {
+	return organizations.NewFromConfig(c.config, func(o *organizations.Options) {
+		o.BaseEndpoint = c.endpoint("organizations")
+	})
+}
+
+func (c *providerClient) ssoadminClient() *ssoadmin.Client {
+	return ssoadmin.NewFromConfig(c.config, func(o *ssoadmin.Options) {
+		o.BaseEndpoint = c.endpoint("ssoadmin")
+	})
+}
+
+func (c *providerClient) identitystoreClient() *identitystore.Client {
+	return identitystore.NewFromConfig(c.config, func(o *identitystore.Options) {
+		o.BaseEndpoint = c.endpoint("identitystore")
+	})
+}
+
+func (c *providerClient) controltowerClient() *controltower.Client {
+	return controltower.NewFromConfig(c.config, func(o *controltower.Options) {
+		o.BaseEndpoint = c.endpoint("controltower")
+	})
+}
+
+func (c *providerClient) stsClient() *sts.Client {
+	return sts.NewFromConfig(c.config, func(o *sts.Options) {
+		o.BaseEndpoint = c.endpoint("sts")
+	})
+}
+
+func (c *providerClient) dynamodbClient() *dynamodb.Client {
+	return dynamodb.NewFromConfig(c.config, func(o *dynamodb.Options) {
+		o.BaseEndpoint = c.endpoint("dynamodb")
+	})
+}
+
+// memo caches the results of lookups by key and is safe for concurrent use. Concurrent lookups
+// of the same key are only done once, failed lookups are not cached.
+type memo[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]*memoEntry[V]
+}
+
+type memoEntry[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+func (m *memo[K, V]) get(key K, lookup func() (V, error)) (V, error) {
+	m.mu.Lock()
+	if m.entries == nil {
+		m.entries = map[K]*memoEntry[V]{}
+	}
+
+	if e, ok := m.entries[key]; ok {
+		m.mu.Unlock()
+		<-e.done
+		return e.value, e.err
+	}
+
+	e := &memoEntry[V]{done: make(chan struct{})}
+	m.entries[key] = e
+	m.mu.Unlock()
+
+	e.value, e.err = lookup()
+	if e.err != nil {
+		m.forget(key)
+	}
+	close(e.done)
+
+	return e.value, e.err
+}
+
+// forget removes the cached result of a key, e.g. after it was changed.
+func (m *memo[K, V]) forget(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+}
+
+type accountFactoryProductIds struct {
+	productId  *string
+	artifactId *string
+}
+
+// findAccountFactoryProduct returns the ID and the active provisioning artifact ID of the Account Factory product.
+func (c *providerClient) findAccountFactoryProduct(ctx context.Context, product accountFactoryProduct) (*string, *string, error) {
+	ids, err := c.accountFactoryProducts.get(product, func() (accountFactoryProductIds, error) {
+		productId, artifactId, err := findServiceCatalogAccountProductId(ctx, c.scconn, product)
+		return accountFactoryProductIds{productId: productId, artifactId: artifactId}, err
+	})
+
+	return ids.productId, ids.artifactId, err
+}
+
+// listSSOInstances returns the IAM Identity Center instances.
+func (c *providerClient) listSSOInstances(ctx context.Context) (*ssoadmin.ListInstancesOutput, error) {
+	return c.ssoInstances.get("", func() (*ssoadmin.ListInstancesOutput, error) {
+		output, err := c.ssoadminconn.ListInstances(ctx, &ssoadmin.ListInstancesInput{})
+		if err != nil {
+			return nil, fmt.Errorf("error listing SSO instances: %w", err)
+		}
+		return output, nil
+	})
+}
+
+// ssoInstance selects an IAM Identity Center instance. If both fields are empty, the only instance is selected.
+type ssoInstance struct {
+	instanceArn     string
+	identityStoreId string
+}
+
+func (i ssoInstance) String() string {
+	switch {
+	case i.instanceArn != "" && i.identityStoreId != "":
+		return fmt.Sprintf("instance %s with identity store %s", i.instanceArn, i.identityStoreId)
+	case i.instanceArn != "":
+		return fmt.Sprintf("instance %s", i.instanceArn)
+	default:
+		return fmt.Sprintf("identity store %s", i.identityStoreId)
+	}
+}
+
+// expandSSOInstance applies the IAM Identity Center instance settings of a resource on top of the provider defaults.
+func expandSSOInstance(d resourceGetter, defaults ssoInstance) ssoInstance {
+	instance := ssoInstance{}
+
+	if v, ok := d.GetOk("sso_instance_arn"); ok {
+		instance.instanceArn = v.(string)
+	}
+	if v, ok := d.GetOk("identity_store_id"); ok {
+		instance.identityStoreId = v.(string)
+	}
+
+	// A selection of the resource replaces the one of the provider, as both might contradict each other.
+	if instance == (ssoInstance{}) {
+		return defaults
+	}
+
+	return instance
+}
+
+// selectSSOInstance returns the instance that matches the selection.
+func selectSSOInstance(instances []ssoTypes.InstanceMetadata, selection ssoInstance) (ssoTypes.InstanceMetadata, error) {
+	var matches []ssoTypes.InstanceMetadata
+	for _, instance := range instances {
+		if selection.instanceArn != "" && aws.ToString(instance.InstanceArn) != selection.instanceArn {
+			continue
+		}
+		if selection.identityStoreId != "" && aws.ToString(instance.IdentityStoreId) != selection.identityStoreId {
+			continue
+		}
+		matches = append(matches, instance)
+	}
+
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) == 0 && selection == (ssoInstance{}):
+		return ssoTypes.InstanceMetadata{}, fmt.Errorf("no IAM Identity Center instance found")
+	case len(matches) == 0:
+		return ssoTypes.InstanceMetadata{}, fmt.Errorf("no IAM Identity Center instance found for %s", selection)
+	default:
+		arns := make([]string, 0, len(matches))
+		for _, instance := range matches {
+			arns = append(arns, aws.ToString(instance.InstanceArn))
+		}
+		return ssoTypes.InstanceMetadata{}, fmt.Errorf("found %d IAM Identity Center instances (%s), set sso_instance_arn or identity_store_id to select one", len(matches), strings.Join(arns, ", "))
+	}
+}
+
+// findSSOInstance returns the selected IAM Identity Center instance.
+func (c *providerClient) findSSOInstance(ctx context.Context, selection ssoInstance) (ssoTypes.InstanceMetadata, error) {
+	ssoInstances, err := c.listSSOInstances(ctx)
+	if err != nil {
+		return ssoTypes.InstanceMetadata{}, err
+	}
+
+	return selectSSOInstance(ssoInstances.Instances, selection)
+}
+
+type permissionSetKey struct {
+	instanceArn string
+	name        string
+}
+
+// findPermissionSetArn returns the ARN of the permission set with the given name.
+func (c *providerClient) findPermissionSetArn(ctx context.Context, instanceArn *string, permissionSetName string) (string, error) {
+	return c.permissionSetArns.get(permissionSetKey{instanceArn: aws.ToString(instanceArn), name: permissionSetName}, func() (string, error) {
+		return findPermissionSetArn(ctx, c.ssoadminconn, instanceArn, permissionSetName)
+	})
+}
+
+// describeOrganizationalUnit returns the organizational unit with the given ID.
+func (c *providerClient) describeOrganizationalUnit(ctx context.Context, id string) (*orgTypes.OrganizationalUnit, error) {
+	return c.organizationalUnits.get(id, func() (*orgTypes.OrganizationalUnit, error) {
+		output, err := c.organizationsconn.DescribeOrganizationalUnit(ctx, &organizations.DescribeOrganizationalUnitInput{
+			OrganizationalUnitId: aws.String(id),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error describing OU %s: %w", id, err)
+		}
+		return output.OrganizationalUnit, nil
+	})
+}
+
+// listBaselineArns returns the ARNs of the Control Tower baselines by name. The returned map must not be modified.
+func (c *providerClient) listBaselineArns(ctx context.Context) (map[string]string, error) {
+	return c.baselineArns.get("", func() (map[string]string, error) {
+		return listBaselineArns(ctx, c.controltowerconn)
+	})
+}
+
+// listOrganizationalUnitsForParent returns the organizational units directly below the given root or OU.
+func (c *providerClient) listOrganizationalUnitsForParent(ctx context.Context, parentId string) ([]orgTypes.OrganizationalUnit, error) {
+	return c.organizationalUnitChildren.get(parentId, func() ([]orgTypes.OrganizationalUnit, error) {
+		var children []orgTypes.OrganizationalUnit
+
+		paginator := organizations.NewListOrganizationalUnitsForParentPaginator(c.organizationsconn, &organizations.ListOrganizationalUnitsForParentInput{
+			ParentId: aws.String(parentId),
+		})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("error listing OUs for parent %s: %w", parentId, err)
+			}
+			children = append(children, output.OrganizationalUnits...)
+		}
+
+		return children, nil
+	})
+}
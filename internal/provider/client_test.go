@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ssoTypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
+)
+
+func TestMemo(t *testing.T) {
+	var (
+		m       memo[string, int]
+		lookups atomic.Int32
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			value, err := m.get("key", func() (int, error) {
+				lookups.Add(1)
+				return 42, nil
+			})
+			if err != nil || value != 42 {
+				t.Errorf("expected 42, got %d (%v)", value, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := lookups.Load(); n != 1 {
+		t.Fatalf("expected a single lookup, got %d", n)
+	}
+}
+
+func TestMemoDoesNotCacheErrors(t *testing.T) {
+	var m memo[string, int]
+
+	if _, err := m.get("key", func() (int, error) { return 0, errors.New("throttled") }); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	value, err := m.get("key", func() (int, error) { return 42, nil })
+	if err != nil || value != 42 {
+		t.Fatalf("expected 42 after failed lookup, got %d (%v)", value, err)
+	}
+}
+
+func TestSelectSSOInstance(t *testing.T) {
+	organizationInstance := ssoTypes.InstanceMetadata{
+		InstanceArn:     aws.String("arn:aws:sso:::instance/ssoins-1111111111111111"),
+		IdentityStoreId: aws.String("d-1111111111"),
+	}
+	accountInstance := ssoTypes.InstanceMetadata{
+		InstanceArn:     aws.String("arn:aws:sso:::instance/ssoins-2222222222222222"),
+		IdentityStoreId: aws.String("d-2222222222"),
+	}
+
+	cases := []struct {
+		name      string
+		instances []ssoTypes.InstanceMetadata
+		selection ssoInstance
+		expected  string
+		ok        bool
+	}{
+		{
+			name:      "only instance",
+			instances: []ssoTypes.InstanceMetadata{organizationInstance},
+			expected:  "d-1111111111",
+			ok:        true,
+		},
+		{
+			name:      "no instance",
+			instances: nil,
+		},
+		{
+			name:      "ambiguous",
+			instances: []ssoTypes.InstanceMetadata{organizationInstance, accountInstance},
+		},
+		{
+			name:      "by instance arn",
+			instances: []ssoTypes.InstanceMetadata{organizationInstance, accountInstance},
+			selection: ssoInstance{instanceArn: "arn:aws:sso:::instance/ssoins-2222222222222222"},
+			expected:  "d-2222222222",
+			ok:        true,
+		},
+		{
+			name:      "by identity store id",
+			instances: []ssoTypes.InstanceMetadata{organizationInstance, accountInstance},
+			selection: ssoInstance{identityStoreId: "d-1111111111"},
+			expected:  "d-1111111111",
+			ok:        true,
+		},
+		{
+			name:      "contradicting selection",
+			instances: []ssoTypes.InstanceMetadata{organizationInstance, accountInstance},
+			selection: ssoInstance{instanceArn: "arn:aws:sso:::instance/ssoins-1111111111111111", identityStoreId: "d-2222222222"},
+		},
+	}
+
+	for _, c := range cases {
+		instance, err := selectSSOInstance(c.instances, c.selection)
+		if c.ok != (err == nil) {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+		if c.ok && aws.ToString(instance.IdentityStoreId) != c.expected {
+			t.Errorf("%s: expected identity store %s, got %s", c.name, c.expected, aws.ToString(instance.IdentityStoreId))
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/servicecatalog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceAccountFactoryProduct() *schema.Resource {
+	return &schema.Resource{
+		Description: "Provides the Control Tower Account Factory product in Service Catalog, its provisioning artifacts, launch paths and provisioning parameters.",
+
+		ReadContext: dataSourceAccountFactoryProductRead,
+
+		Schema: map[string]*schema.Schema{
+			"account_factory_product_id": {
+				Description:   "ID of the Account Factory product in Service Catalog. Overrides the provider setting of the same name. Conflicts with `account_factory_product_name`.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"account_factory_product_name"},
+				ValidateFunc:  validation.StringMatch(regexp.MustCompile(`^prod-[a-z0-9]+$`), "must be a Service Catalog product ID"),
+			},
+			"account_factory_product_name": {
+				Description:   "Name of the Account Factory product in Service Catalog. Overrides the provider setting of the same name. Conflicts with `account_factory_product_id`.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"account_factory_product_id"},
+			},
+			"account_factory_product_name_match": {
+				Description:  "How the Account Factory product name is matched. Valid values are `full_text` and `exact`. Overrides the provider setting of the same name.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"full_text", "exact"}, false),
+			},
+			"path_id": {
+				Description:  "ID of the launch path used to describe the provisioning parameters. Required if the product has more than one launch path.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringMatch(regexp.MustCompile(`^[a-zA-Z0-9_-]*$`), "must only contain alphanumeric characters, underscores and hyphens"),
+			},
+			"product_id": {
+				Description: "ID of the Account Factory product.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"active_provisioning_artifact_id": {
+				Description: "ID of the active provisioning artifact (version), which is used for new accounts.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"provisioning_artifacts": {
+				Description: "All provisioning artifacts (versions) of the product.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Description: "ID of the provisioning artifact.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"name": {
+							Description: "Name of the provisioning artifact.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"description": {
+							Description: "Description of the provisioning artifact.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"active": {
+							Description: "Whether the provisioning artifact is active.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+						"guidance": {
+							Description: "Guidance for the provisioning artifact, either `DEFAULT` or `DEPRECATED`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"created_time": {
+							Description: "Time the provisioning artifact was created, in RFC 3339 format.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"launch_paths": {
+				Description: "Launch paths of the product. Their IDs can be used as `path_id` of an account.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Description: "ID of the launch path.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"name": {
+							Description: "Name of the launch path.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"provisioning_parameters": {
+				Description: "Parameters of the active provisioning artifact.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Description: "Key of the parameter.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"description": {
+							Description: "Description of the parameter.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"type": {
+							Description: "Type of the parameter.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"default_value": {
+							Description: "Default value of the parameter.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"allowed_values": {
+							Description: "Values the parameter is restricted to, if any.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"no_echo": {
+							Description: "Whether the value of the parameter is masked.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+func dataSourceAccountFactoryProductRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutRead)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	scconn := client.scconn
+
+	productId, artifactId, err := client.findAccountFactoryProduct(ctx, expandAccountFactoryProduct(d, client.accountFactoryProduct))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	artifacts, err := scconn.ListProvisioningArtifacts(ctx, &servicecatalog.ListProvisioningArtifactsInput{
+		ProductId: productId,
+	})
+	if err != nil {
+		return diag.Errorf("error listing provisioning artifacts: %v", err)
+	}
+
+	provisioningArtifacts := make([]interface{}, 0, len(artifacts.ProvisioningArtifactDetails))
+	for _, artifact := range artifacts.ProvisioningArtifactDetails {
+		var createdTime string
+		if artifact.CreatedTime != nil {
+			createdTime = artifact.CreatedTime.Format(time.RFC3339)
+		}
+
+		provisioningArtifacts = append(provisioningArtifacts, map[string]interface{}{
+			"id":           aws.ToString(artifact.Id),
+			"name":         aws.ToString(artifact.Name),
+			"description":  aws.ToString(artifact.Description),
+			"active":       aws.ToBool(artifact.Active),
+			"guidance":     string(artifact.Guidance),
+			"created_time": createdTime,
+		})
+	}
+
+	var launchPaths []interface{}
+	paginator := servicecatalog.NewListLaunchPathsPaginator(scconn, &servicecatalog.ListLaunchPathsInput{
+		ProductId: productId,
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return diag.Errorf("error listing launch paths: %v", err)
+		}
+
+		for _, path := range output.LaunchPathSummaries {
+			launchPaths = append(launchPaths, map[string]interface{}{
+				"id":   aws.ToString(path.Id),
+				"name": aws.ToString(path.Name),
+			})
+		}
+	}
+
+	params := &servicecatalog.DescribeProvisioningParametersInput{
+		ProductId:              productId,
+		ProvisioningArtifactId: artifactId,
+	}
+	if v, ok := d.GetOk("path_id"); ok {
+		params.PathId = aws.String(v.(string))
+	}
+
+	parameters, err := scconn.DescribeProvisioningParameters(ctx, params)
+	if err != nil {
+		return diag.Errorf("error describing provisioning parameters: %v", err)
+	}
+
+	provisioningParameters := make([]interface{}, 0, len(parameters.ProvisioningArtifactParameters))
+	for _, parameter := range parameters.ProvisioningArtifactParameters {
+		var allowedValues []string
+		if parameter.ParameterConstraints != nil {
+			allowedValues = parameter.ParameterConstraints.AllowedValues
+		}
+
+		provisioningParameters = append(provisioningParameters, map[string]interface{}{
+			"key":            aws.ToString(parameter.ParameterKey),
+			"description":    aws.ToString(parameter.Description),
+			"type":           aws.ToString(parameter.ParameterType),
+			"default_value":  aws.ToString(parameter.DefaultValue),
+			"allowed_values": allowedValues,
+			"no_echo":        parameter.IsNoEcho,
+		})
+	}
+
+	d.SetId(aws.ToString(productId))
+
+	if err := d.Set("product_id", productId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("active_provisioning_artifact_id", artifactId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("provisioning_artifacts", provisioningArtifacts); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("launch_paths", launchPaths); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("provisioning_parameters", provisioningParameters); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
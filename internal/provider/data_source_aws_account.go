@@ -0,0 +1,336 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/servicecatalog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+var accountLookupAttributes = []string{"account_id", "email", "name", "provisioned_product_name"}
+
+func dataSourceAWSAccount() *schema.Resource {
+	return &schema.Resource{
+		Description: "Looks up an AWS account that was vended via Control Tower.",
+
+		ReadContext: dataSourceAWSAccountRead,
+
+		Schema: map[string]*schema.Schema{
+			"account_id": {
+				Description:  "ID of the AWS account to look up.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: accountLookupAttributes,
+				ValidateFunc: validateAccountID,
+			},
+			"email": {
+				Description:  "Root email of the account to look up.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: accountLookupAttributes,
+				ValidateFunc: validateEmailAddress,
+			},
+			"name": {
+				Description:  "Name of the account to look up.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: accountLookupAttributes,
+			},
+			"provisioned_product_name": {
+				Description:  "Name of the service catalog product that provisioned the account to look up.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: accountLookupAttributes,
+			},
+			"account_factory_product_id": {
+				Description:   "ID of the Account Factory product in Service Catalog. Overrides the provider setting of the same name. Conflicts with `account_factory_product_name`.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"account_factory_product_name"},
+				ValidateFunc:  validation.StringMatch(regexp.MustCompile(`^prod-[a-z0-9]+$`), "must be a Service Catalog product ID"),
+			},
+			"account_factory_product_name": {
+				Description:   "Name of the Account Factory product in Service Catalog. Overrides the provider setting of the same name. Conflicts with `account_factory_product_id`.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"account_factory_product_id"},
+			},
+			"account_factory_product_name_match": {
+				Description:  "How the Account Factory product name is matched. Valid values are `full_text` and `exact`. Overrides the provider setting of the same name.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"full_text", "exact"}, false),
+			},
+			"provisioned_product_id": {
+				Description: "ID of the service catalog product that provisioned the account.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"sso": {
+				Description: "Assigned SSO user settings.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"email": {
+							Description: "Email address of the user.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"organizational_unit": {
+				Description: "Name of the Organizational Unit under which the account resides.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"path_id": {
+				Description: "Name of the path identifier of the product.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"provisioning_artifact_id": {
+				Description: "ID of the provisioning artifact (version) of the Account Factory product in use.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"tags": {
+				Description: "Key-value map of tags assigned to the account.",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+func dataSourceAWSAccountRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutRead)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	scconn := client.scconn
+	organizationsconn := client.organizationsconn
+
+	var provisionedProductId *string
+	if v, ok := d.GetOk("provisioned_product_name"); ok {
+		product, err := scconn.DescribeProvisionedProduct(ctx, &servicecatalog.DescribeProvisionedProductInput{
+			Name: aws.String(v.(string)),
+		})
+		if err != nil {
+			return diag.Errorf("error reading provisioned product %s: %v", v, err)
+		}
+		provisionedProductId = product.ProvisionedProductDetail.Id
+	} else {
+		lookup := accountLookup{
+			accountId: d.Get("account_id").(string),
+			email:     d.Get("email").(string),
+		}
+
+		// Record outputs do not contain the account name, so it is resolved to an ID first.
+		if v, ok := d.GetOk("name"); ok {
+			accountId, err := findAccountIdByName(ctx, organizationsconn, v.(string))
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			lookup.accountId = accountId
+		}
+
+		productId, _, err := client.findAccountFactoryProduct(ctx, expandAccountFactoryProduct(d, client.accountFactoryProduct))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		provisionedProductId, err = findProvisionedAccountProductId(ctx, scconn, productId, lookup)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	product, err := scconn.DescribeProvisionedProduct(ctx, &servicecatalog.DescribeProvisionedProductInput{
+		Id: provisionedProductId,
+	})
+	if err != nil {
+		return diag.Errorf("error reading configuration of provisioned product: %v", err)
+	}
+	if product.ProvisionedProductDetail.LastSuccessfulProvisioningRecordId == nil {
+		return diag.Errorf("provisioned product %s has not been provisioned successfully", aws.ToString(provisionedProductId))
+	}
+
+	status, err := scconn.DescribeRecord(ctx, &servicecatalog.DescribeRecordInput{
+		Id: product.ProvisionedProductDetail.LastSuccessfulProvisioningRecordId,
+	})
+	if err != nil {
+		return diag.Errorf("error reading last successful record of provisioned product: %v", err)
+	}
+
+	outputs := parseAccountRecordOutputs(status.RecordOutputs)
+	if outputs.accountId == "" {
+		return diag.Errorf("no account ID found in the outputs of provisioned product %s", aws.ToString(provisionedProductId))
+	}
+
+	d.SetId(aws.ToString(provisionedProductId))
+
+	if err := d.Set("provisioned_product_id", provisionedProductId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("provisioned_product_name", product.ProvisionedProductDetail.Name); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("provisioning_artifact_id", product.ProvisionedProductDetail.ProvisioningArtifactId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("path_id", status.RecordDetail.PathId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("account_id", outputs.accountId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("email", outputs.accountEmail); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("sso", []interface{}{map[string]interface{}{"email": outputs.ssoUserEmail}}); err != nil {
+		return diag.FromErr(err)
+	}
+
+	account, err := organizationsconn.DescribeAccount(ctx, &organizations.DescribeAccountInput{
+		AccountId: aws.String(outputs.accountId),
+	})
+	if err != nil {
+		return diag.Errorf("error reading account information for %s: %v", outputs.accountId, err)
+	}
+	if err := d.Set("name", account.Account.Name); err != nil {
+		return diag.FromErr(err)
+	}
+
+	ou, err := findParentOrganizationalUnit(ctx, client, outputs.accountId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("organizational_unit", ou.Name); err != nil {
+		return diag.FromErr(err)
+	}
+
+	tags, err := organizationsconn.ListTagsForResource(ctx, &organizations.ListTagsForResourceInput{
+		ResourceId: aws.String(outputs.accountId),
+	})
+	if err != nil {
+		return diag.Errorf("error listing tags for resource %s: %v", outputs.accountId, err)
+	}
+	if err := d.Set("tags", fromOrganizationTags(tags.Tags, client.ignoreTags)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+// accountLookup describes the account a data source is looking for.
+type accountLookup struct {
+	accountId string
+	email     string
+}
+
+// matches reports whether the outputs of a provisioning record belong to the account.
+func (l accountLookup) matches(outputs accountRecordOutputs) bool {
+	if l.accountId != "" && outputs.accountId != l.accountId {
+		return false
+	}
+	if l.email != "" && !strings.EqualFold(outputs.accountEmail, l.email) {
+		return false
+	}
+	return l.accountId != "" || l.email != ""
+}
+
+func (l accountLookup) String() string {
+	if l.accountId != "" {
+		return l.accountId
+	}
+	return l.email
+}
+
+// findProvisionedAccountProductId searches the provisioned Account Factory products for the given account.
+func findProvisionedAccountProductId(ctx context.Context, client *servicecatalog.Client, productId *string, lookup accountLookup) (*string, error) {
+	var matches []*string
+
+	paginator := servicecatalog.NewSearchProvisionedProductsPaginator(client, &servicecatalog.SearchProvisionedProductsInput{
+		Filters: map[string][]string{
+			"SearchQuery": {"productId:" + aws.ToString(productId)},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error searching provisioned products: %w", err)
+		}
+
+		for _, product := range page.ProvisionedProducts {
+			if product.LastSuccessfulProvisioningRecordId == nil {
+				continue
+			}
+
+			record, err := client.DescribeRecord(ctx, &servicecatalog.DescribeRecordInput{
+				Id: product.LastSuccessfulProvisioningRecordId,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("error reading last successful record of provisioned product %s: %w", aws.ToString(product.Id), err)
+			}
+
+			if lookup.matches(parseAccountRecordOutputs(record.RecordOutputs)) {
+				matches = append(matches, product.Id)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("could not find a provisioned product for account %s", lookup)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("found %d provisioned products for account %s", len(matches), lookup)
+	}
+}
+
+// findAccountIdByName returns the ID of the only account in the organization with the given name.
+func findAccountIdByName(ctx context.Context, client *organizations.Client, name string) (string, error) {
+	var ids []string
+
+	paginator := organizations.NewListAccountsPaginator(client, &organizations.ListAccountsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("error listing accounts: %w", err)
+		}
+
+		for _, account := range output.Accounts {
+			if aws.ToString(account.Name) == name {
+				ids = append(ids, aws.ToString(account.Id))
+			}
+		}
+	}
+
+	if len(ids) != 1 {
+		return "", fmt.Errorf("unexpected number of accounts named %q: %d", name, len(ids))
+	}
+
+	return ids[0], nil
+}
@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	scTypes "github.com/aws/aws-sdk-go-v2/service/servicecatalog/types"
+)
+
+func TestParseAccountRecordOutputs(t *testing.T) {
+	got := parseAccountRecordOutputs([]scTypes.RecordOutput{
+		{OutputKey: aws.String("AccountId"), OutputValue: aws.String("123456789012")},
+		{OutputKey: aws.String("AccountEmail"), OutputValue: aws.String("aws-admin@example.com")},
+		{OutputKey: aws.String("SSOUserEmail"), OutputValue: aws.String("john.doe@example.com")},
+		{OutputKey: aws.String("SSOUserPortal"), OutputValue: aws.String("https://example.awsapps.com/start")},
+		{OutputKey: aws.String("CloudformationStackARN")},
+	})
+
+	want := accountRecordOutputs{
+		accountId:    "123456789012",
+		accountEmail: "aws-admin@example.com",
+		ssoUserEmail: "john.doe@example.com",
+	}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestAccountLookupMatches(t *testing.T) {
+	outputs := accountRecordOutputs{
+		accountId:    "123456789012",
+		accountEmail: "aws-admin@example.com",
+	}
+
+	cases := []struct {
+		lookup accountLookup
+		want   bool
+	}{
+		{accountLookup{accountId: "123456789012"}, true},
+		{accountLookup{accountId: "210987654321"}, false},
+		{accountLookup{email: "AWS-Admin@example.com"}, true},
+		{accountLookup{email: "someone@example.com"}, false},
+		{accountLookup{}, false},
+	}
+
+	for _, c := range cases {
+		if got := c.lookup.matches(outputs); got != c.want {
+			t.Errorf("lookup %+v: expected %t, got %t", c.lookup, c.want, got)
+		}
+	}
+}
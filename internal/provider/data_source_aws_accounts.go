@@ -0,0 +1,401 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgTypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicecatalog"
+	scTypes "github.com/aws/aws-sdk-go-v2/service/servicecatalog/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceAWSAccounts() *schema.Resource {
+	var provisionedProductStatuses []string
+	for _, status := range scTypes.ProvisionedProductStatus("").Values() {
+		provisionedProductStatuses = append(provisionedProductStatuses, string(status))
+	}
+
+	return &schema.Resource{
+		Description: "Lists the AWS accounts that were vended via Control Tower Account Factory.",
+
+		ReadContext: dataSourceAWSAccountsRead,
+
+		Schema: map[string]*schema.Schema{
+			"organizational_unit": {
+				Description: "Only list accounts in the Organizational Unit with this name.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"include_nested_organizational_units": {
+				Description:  "If enabled, accounts in Organizational Units nested below `organizational_unit` are listed as well.",
+				Type:         schema.TypeBool,
+				Optional:     true,
+				Default:      false,
+				RequiredWith: []string{"organizational_unit"},
+			},
+			"tags": {
+				Description: "Only list accounts that have all of these tags.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"statuses": {
+				Description: "Only list accounts whose provisioned product has one of these statuses, e.g. `AVAILABLE`.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(provisionedProductStatuses, false),
+				},
+			},
+			"name_regex": {
+				Description:  "Only list accounts whose name matches this regular expression.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"account_factory_product_id": {
+				Description:   "ID of the Account Factory product in Service Catalog. Overrides the provider setting of the same name. Conflicts with `account_factory_product_name`.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"account_factory_product_name"},
+				ValidateFunc:  validation.StringMatch(regexp.MustCompile(`^prod-[a-z0-9]+$`), "must be a Service Catalog product ID"),
+			},
+			"account_factory_product_name": {
+				Description:   "Name of the Account Factory product in Service Catalog. Overrides the provider setting of the same name. Conflicts with `account_factory_product_id`.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"account_factory_product_id"},
+			},
+			"account_factory_product_name_match": {
+				Description:  "How the Account Factory product name is matched. Valid values are `full_text` and `exact`. Overrides the provider setting of the same name.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"full_text", "exact"}, false),
+			},
+			"account_ids": {
+				Description: "IDs of the listed accounts.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"accounts": {
+				Description: "The listed accounts, sorted by name.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"account_id": {
+							Description: "ID of the AWS account.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"name": {
+							Description: "Name of the account.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"email": {
+							Description: "Root email of the account.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"account_status": {
+							Description: "Status of the account in AWS Organizations, e.g. `ACTIVE` or `SUSPENDED`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"organizational_unit": {
+							Description: "Name of the Organizational Unit under which the account resides. Empty for accounts directly below the organization root.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"organizational_unit_id": {
+							Description: "ID of the Organizational Unit under which the account resides. Empty for accounts directly below the organization root.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"provisioned_product_id": {
+							Description: "ID of the service catalog product that provisioned the account.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"provisioned_product_name": {
+							Description: "Name of the service catalog product that provisioned the account.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"status": {
+							Description: "Status of the provisioned product, e.g. `AVAILABLE` or `TAINTED`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"tags": {
+							Description: "Key-value map of tags assigned to the account.",
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+func dataSourceAWSAccountsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutRead)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	scconn := client.scconn
+	organizationsconn := client.organizationsconn
+
+	filter, err := expandAccountsFilter(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if v, ok := d.GetOk("organizational_unit"); ok {
+		filter.organizationalUnitIds, err = findOrganizationalUnitIds(ctx, client, v.(string), d.Get("include_nested_organizational_units").(bool))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	productId, _, err := client.findAccountFactoryProduct(ctx, expandAccountFactoryProduct(d, client.accountFactoryProduct))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var accounts []map[string]interface{}
+
+	paginator := servicecatalog.NewSearchProvisionedProductsPaginator(scconn, &servicecatalog.SearchProvisionedProductsInput{
+		Filters: map[string][]string{
+			"SearchQuery": {"productId:" + aws.ToString(productId)},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return diag.Errorf("error searching provisioned products: %v", err)
+		}
+
+		for _, product := range page.ProvisionedProducts {
+			// Accounts that were never provisioned successfully have no outputs to resolve.
+			if product.LastSuccessfulProvisioningRecordId == nil || !filter.matchesStatus(string(product.Status)) {
+				continue
+			}
+
+			record, err := scconn.DescribeRecord(ctx, &servicecatalog.DescribeRecordInput{
+				Id: product.LastSuccessfulProvisioningRecordId,
+			})
+			if err != nil {
+				return diag.Errorf("error reading last successful record of provisioned product %s: %v", aws.ToString(product.Id), err)
+			}
+
+			outputs := parseAccountRecordOutputs(record.RecordOutputs)
+			if outputs.accountId == "" {
+				continue
+			}
+
+			account, err := organizationsconn.DescribeAccount(ctx, &organizations.DescribeAccountInput{
+				AccountId: aws.String(outputs.accountId),
+			})
+			if err != nil {
+				return diag.Errorf("error reading account information for %s: %v", outputs.accountId, err)
+			}
+			if !filter.matchesName(aws.ToString(account.Account.Name)) {
+				continue
+			}
+
+			ou, err := findParentOrganizationalUnit(ctx, client, outputs.accountId)
+			if errors.Is(err, errNoParentOrganizationalUnit) {
+				// Accounts directly below the root, e.g. the management account, have no OU.
+				ou, err = &orgTypes.OrganizationalUnit{}, nil
+			}
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			if !filter.matchesOrganizationalUnit(aws.ToString(ou.Id)) {
+				continue
+			}
+
+			tags, err := organizationsconn.ListTagsForResource(ctx, &organizations.ListTagsForResourceInput{
+				ResourceId: aws.String(outputs.accountId),
+			})
+			if err != nil {
+				return diag.Errorf("error listing tags for resource %s: %v", outputs.accountId, err)
+			}
+			accountTags := fromOrganizationTags(tags.Tags, client.ignoreTags)
+			if !filter.matchesTags(accountTags) {
+				continue
+			}
+
+			accounts = append(accounts, map[string]interface{}{
+				"account_id":               outputs.accountId,
+				"name":                     aws.ToString(account.Account.Name),
+				"email":                    outputs.accountEmail,
+				"account_status":           string(account.Account.Status),
+				"organizational_unit":      aws.ToString(ou.Name),
+				"organizational_unit_id":   aws.ToString(ou.Id),
+				"provisioned_product_id":   aws.ToString(product.Id),
+				"provisioned_product_name": aws.ToString(product.Name),
+				"status":                   string(product.Status),
+				"tags":                     flattenTags(accountTags),
+			})
+		}
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i]["name"] != accounts[j]["name"] {
+			return accounts[i]["name"].(string) < accounts[j]["name"].(string)
+		}
+		return accounts[i]["account_id"].(string) < accounts[j]["account_id"].(string)
+	})
+
+	accountIds := make([]string, 0, len(accounts))
+	result := make([]interface{}, 0, len(accounts))
+	for _, account := range accounts {
+		accountIds = append(accountIds, account["account_id"].(string))
+		result = append(result, account)
+	}
+
+	d.SetId(aws.ToString(productId))
+
+	if err := d.Set("account_ids", accountIds); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("accounts", result); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+// accountsFilter selects the accounts listed by the controltower_aws_accounts data source.
+// Empty fields do not filter.
+type accountsFilter struct {
+	statuses  map[string]bool
+	nameRegex *regexp.Regexp
+	tags      map[string]string
+	// organizationalUnitIds are the OUs accounts have to reside in directly, nil means any.
+	organizationalUnitIds map[string]bool
+}
+
+func expandAccountsFilter(d *schema.ResourceData) (accountsFilter, error) {
+	var filter accountsFilter
+
+	if v, ok := d.GetOk("statuses"); ok {
+		filter.statuses = map[string]bool{}
+		for _, status := range v.(*schema.Set).List() {
+			filter.statuses[status.(string)] = true
+		}
+	}
+
+	if v, ok := d.GetOk("name_regex"); ok {
+		nameRegex, err := regexp.Compile(v.(string))
+		if err != nil {
+			return filter, fmt.Errorf("error compiling name_regex: %w", err)
+		}
+		filter.nameRegex = nameRegex
+	}
+
+	if v, ok := d.GetOk("tags"); ok {
+		filter.tags = map[string]string{}
+		for k, v := range v.(map[string]interface{}) {
+			filter.tags[k] = v.(string)
+		}
+	}
+
+	return filter, nil
+}
+
+func (f accountsFilter) matchesStatus(status string) bool {
+	return len(f.statuses) == 0 || f.statuses[status]
+}
+
+func (f accountsFilter) matchesName(name string) bool {
+	return f.nameRegex == nil || f.nameRegex.MatchString(name)
+}
+
+func (f accountsFilter) matchesOrganizationalUnit(id string) bool {
+	return f.organizationalUnitIds == nil || f.organizationalUnitIds[id]
+}
+
+func (f accountsFilter) matchesTags(tags map[string]*string) bool {
+	for k, v := range f.tags {
+		if value, ok := tags[k]; !ok || aws.ToString(value) != v {
+			return false
+		}
+	}
+	return true
+}
+
+// findOrganizationalUnitIds returns the IDs of all OUs with the given name and, if nested is set,
+// of all OUs below them.
+func findOrganizationalUnitIds(ctx context.Context, client *providerClient, name string, nested bool) (map[string]bool, error) {
+	ids := map[string]bool{}
+
+	var walk func(parentId string, inside bool) error
+	walk = func(parentId string, inside bool) error {
+		children, err := client.listOrganizationalUnitsForParent(ctx, parentId)
+		if err != nil {
+			return err
+		}
+
+		for _, ou := range children {
+			matches := aws.ToString(ou.Name) == name || (nested && inside)
+			if matches {
+				ids[aws.ToString(ou.Id)] = true
+			}
+			if err := walk(aws.ToString(ou.Id), matches); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	paginator := organizations.NewListRootsPaginator(client.organizationsconn, &organizations.ListRootsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error listing organization roots: %w", err)
+		}
+
+		for _, root := range output.Roots {
+			if err := walk(aws.ToString(root.Id), false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no OU named %q found", name)
+	}
+
+	return ids, nil
+}
+
+func flattenTags(tags map[string]*string) map[string]interface{} {
+	result := make(map[string]interface{}, len(tags))
+	for k, v := range tags {
+		result[k] = aws.ToString(v)
+	}
+	return result
+}
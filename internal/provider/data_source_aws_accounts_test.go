@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestAccountsFilter(t *testing.T) {
+	filter := accountsFilter{
+		statuses:              map[string]bool{"AVAILABLE": true},
+		nameRegex:             regexp.MustCompile("^prod-"),
+		tags:                  map[string]string{"team-name": "platform"},
+		organizationalUnitIds: map[string]bool{"ou-abcd-12345678": true},
+	}
+
+	if !filter.matchesStatus("AVAILABLE") || filter.matchesStatus("TAINTED") {
+		t.Errorf("expected only AVAILABLE to match the statuses")
+	}
+	if !filter.matchesName("prod-network") || filter.matchesName("dev-network") {
+		t.Errorf("expected only prod- names to match the name regex")
+	}
+	if !filter.matchesOrganizationalUnit("ou-abcd-12345678") || filter.matchesOrganizationalUnit("ou-abcd-87654321") {
+		t.Errorf("expected only ou-abcd-12345678 to match the OUs")
+	}
+	if !filter.matchesTags(map[string]*string{"team-name": aws.String("platform"), "cost-center": aws.String("engineering")}) {
+		t.Errorf("expected tags including team-name=platform to match")
+	}
+	if filter.matchesTags(map[string]*string{"team-name": aws.String("security")}) {
+		t.Errorf("expected team-name=security not to match")
+	}
+}
+
+func TestAccountsFilterEmpty(t *testing.T) {
+	var filter accountsFilter
+
+	if !filter.matchesStatus("ERROR") || !filter.matchesName("any") || !filter.matchesOrganizationalUnit("ou-abcd-12345678") || !filter.matchesTags(nil) {
+		t.Fatalf("expected an empty filter to match everything")
+	}
+}
@@ -0,0 +1,406 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ctTypes "github.com/aws/aws-sdk-go-v2/service/controltower/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	scTypes "github.com/aws/aws-sdk-go-v2/service/servicecatalog/types"
+	ssoTypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
+)
+
+const (
+	defaultProvisioningLockId  = "terraform-provider-controltower/account-factory"
+	defaultProvisioningLockTTL = time.Hour
+)
+
+// provisioningLock limits the number of concurrent Account Factory operations, as Control Tower
+// rejects operations beyond its concurrency limit.
+type provisioningLock interface {
+	// acquire blocks until one of the lock slots is held or the context is done, and returns a
+	// function that releases the slot.
+	acquire(ctx context.Context) (func(), error)
+}
+
+// lockOwnerId returns a random identifier for a single lock acquisition.
+func lockOwnerId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// slotName returns the name of one of the lock slots. A single slot keeps the plain name.
+func slotName(name string, slot int, slots int) string {
+	if slots <= 1 {
+		return name
+	}
+	return fmt.Sprintf("%s.%d", name, slot)
+}
+
+// waitForLock calls tryAcquire until it succeeds, the wait timeout is reached or the context is done.
+func waitForLock(ctx context.Context, name string, waitTimeout time.Duration, interval time.Duration, tryAcquire func(ctx context.Context) (bool, error)) error {
+	if waitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, waitTimeout)
+		defer cancel()
+	}
+
+	for {
+		acquired, err := tryAcquire(ctx)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+
+		log.Printf("[DEBUG] Provisioning lock %s is held by another process, waiting", name)
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("timeout reached while waiting for provisioning lock %s: %v", name, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// memoryLock is a semaphore that limits the number of concurrent operations within the provider process.
+type memoryLock struct {
+	slots chan struct{}
+}
+
+func newMemoryLock(slots int) *memoryLock {
+	return &memoryLock{slots: make(chan struct{}, slots)}
+}
+
+func (l *memoryLock) acquire(ctx context.Context) (func(), error) {
+	select {
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timeout reached while waiting for provisioning lock: %v", ctx.Err())
+	}
+}
+
+// fileLock limits the operations of all processes that share a file system, e.g. on a shared runner.
+// Each slot is a lock file that contains the owner and the expiry, so locks of crashed processes
+// expire after the TTL. The expiry is renewed while the lock is held.
+type fileLock struct {
+	path        string
+	slots       int
+	ttl         time.Duration
+	waitTimeout time.Duration
+}
+
+func (l *fileLock) acquire(ctx context.Context) (func(), error) {
+	owner := lockOwnerId()
+	var path string
+
+	err := waitForLock(ctx, l.path, l.waitTimeout, time.Second, func(context.Context) (bool, error) {
+		for slot := 0; slot < l.slots; slot++ {
+			path = slotName(l.path, slot, l.slots)
+
+			acquired, err := l.tryAcquire(path, owner)
+			if err != nil || acquired {
+				return acquired, err
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stopRenewal := renewLock(path, l.ttl, func() error {
+		return l.renew(path, owner)
+	})
+
+	return func() {
+		stopRenewal()
+
+		// Only remove the lock file if it was not taken over after expiring.
+		if current, _, ok := readLockFile(path); ok && current == owner {
+			if err := os.Remove(path); err != nil {
+				log.Printf("[WARN] Error removing provisioning lock %s: %v", path, err)
+			}
+		}
+	}, nil
+}
+
+func (l *fileLock) tryAcquire(path string, owner string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		if expiredOwner, expires, ok := readLockFile(path); ok && time.Now().After(expires) {
+			removeExpiredLockFile(path, expiredOwner)
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error creating lock file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "%s\n%d\n", owner, time.Now().Add(l.ttl).Unix()); err != nil {
+		_ = os.Remove(path)
+		return false, fmt.Errorf("error writing lock file %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// removeExpiredLockFile removes an expired lock file, so it can be acquired again by creating it. Expired
+// locks are only removed while holding a guard file, so a lock that was acquired again after another process
+// removed the expired one is never removed.
+func removeExpiredLockFile(path string, expiredOwner string) {
+	guard := path + ".takeover"
+
+	f, err := os.OpenFile(guard, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		// The guard is only held for a moment, unless its process crashed.
+		if info, err := os.Stat(guard); err == nil && time.Since(info.ModTime()) > time.Minute {
+			log.Printf("[WARN] Removing stale provisioning lock guard %s", guard)
+			_ = os.Remove(guard)
+		}
+		return
+	}
+	f.Close()
+	defer os.Remove(guard)
+
+	if current, expires, ok := readLockFile(path); ok && current == expiredOwner && time.Now().After(expires) {
+		log.Printf("[WARN] Removing expired provisioning lock %s", path)
+		_ = os.Remove(path)
+	}
+}
+
+// renew extends the expiry of a held lock file. The new content is written to a temporary file first,
+// so the lock file is never seen partially written.
+func (l *fileLock) renew(path string, owner string) error {
+	if current, _, ok := readLockFile(path); !ok || current != owner {
+		return fmt.Errorf("provisioning lock %s is no longer held", path)
+	}
+
+	tmp := path + "." + owner + ".tmp"
+	if err := os.WriteFile(tmp, []byte(fmt.Sprintf("%s\n%d\n", owner, time.Now().Add(l.ttl).Unix())), 0o644); err != nil {
+		return fmt.Errorf("error writing lock file %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("error renewing lock file %s: %w", path, err)
+	}
+	return nil
+}
+
+// renewLock renews a held lock every third of its TTL, so operations that run longer than the TTL keep
+// their lock. The returned function stops the renewal.
+func renewLock(name string, ttl time.Duration, renew func() error) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := renew(); err != nil {
+					log.Printf("[WARN] Error renewing provisioning lock %s: %v", name, err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// readLockFile returns the owner and expiry of a lock file.
+func readLockFile(path string) (string, time.Time, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		// The lock file is still being written.
+		return "", time.Time{}, false
+	}
+
+	expires, err := strconv.ParseInt(lines[1], 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	return lines[0], time.Unix(expires, 0), true
+}
+
+// dynamodbLock limits operations across machines using a DynamoDB table with a `LockID` string
+// partition key, which is the same layout as the Terraform S3 backend lock table. Each slot is an item,
+// whose expiry is renewed while the lock is held.
+type dynamodbLock struct {
+	client      *dynamodb.Client
+	table       string
+	id          string
+	slots       int
+	ttl         time.Duration
+	waitTimeout time.Duration
+}
+
+func (l *dynamodbLock) acquire(ctx context.Context) (func(), error) {
+	owner := lockOwnerId()
+	var id string
+
+	err := waitForLock(ctx, l.id, l.waitTimeout, 5*time.Second, func(ctx context.Context) (bool, error) {
+		for slot := 0; slot < l.slots; slot++ {
+			id = slotName(l.id, slot, l.slots)
+
+			acquired, err := l.tryAcquire(ctx, id, owner)
+			if err != nil || acquired {
+				return acquired, err
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stopRenewal := renewLock(id, l.ttl, func() error {
+		return l.renew(id, owner)
+	})
+
+	return func() {
+		stopRenewal()
+
+		// The operation context may already be done, so use a separate one for the release.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		_, err := l.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(l.table),
+			Key: map[string]dynamodbTypes.AttributeValue{
+				"LockID": &dynamodbTypes.AttributeValueMemberS{Value: id},
+			},
+			// OWNER is a reserved word in DynamoDB expressions.
+			ConditionExpression:      aws.String("#owner = :owner"),
+			ExpressionAttributeNames: map[string]string{"#owner": "Owner"},
+			ExpressionAttributeValues: map[string]dynamodbTypes.AttributeValue{
+				":owner": &dynamodbTypes.AttributeValueMemberS{Value: owner},
+			},
+		})
+		if err != nil {
+			log.Printf("[WARN] Error releasing provisioning lock %s in table %s: %v", id, l.table, err)
+		}
+	}, nil
+}
+
+func (l *dynamodbLock) tryAcquire(ctx context.Context, id string, owner string) (bool, error) {
+	now := time.Now()
+
+	_, err := l.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(l.table),
+		Item: map[string]dynamodbTypes.AttributeValue{
+			"LockID":  &dynamodbTypes.AttributeValueMemberS{Value: id},
+			"Owner":   &dynamodbTypes.AttributeValueMemberS{Value: owner},
+			"Expires": &dynamodbTypes.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(l.ttl).Unix(), 10)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(LockID) OR Expires < :now"),
+		ExpressionAttributeValues: map[string]dynamodbTypes.AttributeValue{
+			":now": &dynamodbTypes.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	})
+
+	var conditionErr *dynamodbTypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error acquiring provisioning lock %s in table %s: %w", id, l.table, err)
+	}
+	return true, nil
+}
+
+// idempotencyToken returns a random token for Service Catalog operations. The same token must be passed
+// to all retries of an operation, so a retry after a call that succeeded despite an error has no effect.
+func idempotencyToken() string {
+	return lockOwnerId()
+}
+
+// renew extends the expiry of a held lock item.
+func (l *dynamodbLock) renew(id string, owner string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	_, err := l.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(l.table),
+		Key: map[string]dynamodbTypes.AttributeValue{
+			"LockID": &dynamodbTypes.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:         aws.String("SET Expires = :expires"),
+		ConditionExpression:      aws.String("#owner = :owner"),
+		ExpressionAttributeNames: map[string]string{"#owner": "Owner"},
+		ExpressionAttributeValues: map[string]dynamodbTypes.AttributeValue{
+			":owner":   &dynamodbTypes.AttributeValueMemberS{Value: owner},
+			":expires": &dynamodbTypes.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(l.ttl).Unix(), 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error renewing provisioning lock %s in table %s: %w", id, l.table, err)
+	}
+	return nil
+}
+
+// retryOnConflict retries an Account Factory or Control Tower operation with an exponential backoff
+// as long as it is rejected because of other operations in progress. Service Catalog operations must
+// use an idempotency token that stays the same across retries.
+func retryOnConflict(ctx context.Context, name string, operation func() error) error {
+	delay := 10 * time.Second
+
+	for {
+		err := operation()
+		if err == nil || !isConflictError(err) {
+			return err
+		}
+
+		log.Printf("[DEBUG] Operation for %s conflicts with another operation, retrying in %s: %v", name, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("timeout reached while retrying conflicting operation: %w", err)
+		case <-timer.C:
+		}
+
+		delay = min(2*delay, 2*time.Minute)
+	}
+}
+
+// isConflictError reports whether an operation was rejected because of concurrent operations.
+func isConflictError(err error) bool {
+	var inUseErr *scTypes.ResourceInUseException
+	var invalidStateErr *scTypes.InvalidStateException
+	var controltowerConflictErr *ctTypes.ConflictException
+	var ssoadminConflictErr *ssoTypes.ConflictException
+
+	return errors.As(err, &inUseErr) || errors.As(err, &invalidStateErr) || errors.As(err, &controltowerConflictErr) || errors.As(err, &ssoadminConflictErr)
+}
@@ -0,0 +1,346 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ctTypes "github.com/aws/aws-sdk-go-v2/service/controltower/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	scTypes "github.com/aws/aws-sdk-go-v2/service/servicecatalog/types"
+	ssoTypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "account-factory.lock")
+	lock := &fileLock{
+		path:        path,
+		slots:       1,
+		ttl:         time.Hour,
+		waitTimeout: 2 * time.Second,
+	}
+
+	release, err := lock.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error acquiring lock: %v", err)
+	}
+
+	if _, err := lock.acquire(context.Background()); err == nil {
+		t.Fatalf("expected error acquiring a held lock")
+	}
+
+	release()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected lock file to be removed, got: %v", err)
+	}
+
+	release, err = lock.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error acquiring released lock: %v", err)
+	}
+	release()
+}
+
+func TestFileLockExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "account-factory.lock")
+	lock := &fileLock{
+		path:        path,
+		slots:       1,
+		ttl:         time.Hour,
+		waitTimeout: 5 * time.Second,
+	}
+
+	expired := fmt.Sprintf("crashed-owner\n%d\n", time.Now().Add(-time.Minute).Unix())
+	if err := os.WriteFile(path, []byte(expired), 0o644); err != nil {
+		t.Fatalf("unexpected error writing lock file: %v", err)
+	}
+
+	release, err := lock.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error acquiring expired lock: %v", err)
+	}
+	release()
+}
+
+func TestFileLockSlots(t *testing.T) {
+	lock := &fileLock{
+		path:        filepath.Join(t.TempDir(), "account-factory.lock"),
+		slots:       2,
+		ttl:         time.Hour,
+		waitTimeout: 2 * time.Second,
+	}
+
+	releaseFirst, err := lock.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error acquiring first slot: %v", err)
+	}
+	releaseSecond, err := lock.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error acquiring second slot: %v", err)
+	}
+
+	if _, err := lock.acquire(context.Background()); err == nil {
+		t.Fatalf("expected error acquiring a lock without free slots")
+	}
+
+	releaseFirst()
+	release, err := lock.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error acquiring released slot: %v", err)
+	}
+	release()
+	releaseSecond()
+}
+
+func TestMemoryLock(t *testing.T) {
+	lock := newMemoryLock(2)
+
+	releaseFirst, err := lock.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error acquiring first slot: %v", err)
+	}
+	releaseSecond, err := lock.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error acquiring second slot: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := lock.acquire(ctx); err == nil {
+		t.Fatalf("expected error acquiring a lock without free slots")
+	}
+
+	releaseFirst()
+	releaseSecond()
+}
+
+func TestIsConflictError(t *testing.T) {
+	cases := []struct {
+		err      error
+		conflict bool
+	}{
+		{err: fmt.Errorf("operation error: %w", &scTypes.ResourceInUseException{}), conflict: true},
+		{err: fmt.Errorf("operation error: %w", &scTypes.InvalidStateException{}), conflict: true},
+		{err: fmt.Errorf("operation error: %w", &ctTypes.ConflictException{}), conflict: true},
+		{err: fmt.Errorf("operation error: %w", &ssoTypes.ConflictException{}), conflict: true},
+		{err: fmt.Errorf("operation error: %w", &scTypes.InvalidParametersException{Message: aws.String("parameter is in use by a concurrent update in progress")})},
+		{err: fmt.Errorf("operation error: %w", &smithy.GenericAPIError{Code: "ThrottlingException", Message: "too many concurrent requests"})},
+	}
+
+	for _, c := range cases {
+		if conflict := isConflictError(c.err); conflict != c.conflict {
+			t.Errorf("%v: expected %t, got %t", c.err, c.conflict, conflict)
+		}
+	}
+}
+
+func TestFileLockRenewed(t *testing.T) {
+	lock := &fileLock{
+		path:        filepath.Join(t.TempDir(), "account-factory.lock"),
+		slots:       1,
+		ttl:         3 * time.Second,
+		waitTimeout: 5 * time.Second,
+	}
+
+	release, err := lock.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error acquiring lock: %v", err)
+	}
+	defer release()
+
+	// The lock is held longer than its TTL, so it is only kept by renewing it.
+	if _, err := lock.acquire(context.Background()); err == nil {
+		t.Fatalf("expected error acquiring a renewed lock")
+	}
+}
+
+func TestFileLockExpiredTakenOverOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "account-factory.lock")
+	lock := &fileLock{
+		path:  path,
+		slots: 1,
+		ttl:   time.Hour,
+	}
+
+	expired := fmt.Sprintf("crashed-owner\n%d\n", time.Now().Add(-time.Minute).Unix())
+	if err := os.WriteFile(path, []byte(expired), 0o644); err != nil {
+		t.Fatalf("unexpected error writing lock file: %v", err)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		acquired atomic.Int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			owner := lockOwnerId()
+			for attempt := 0; attempt < 3; attempt++ {
+				ok, err := lock.tryAcquire(path, owner)
+				if err != nil {
+					t.Errorf("unexpected error acquiring expired lock: %v", err)
+					return
+				}
+				if ok {
+					acquired.Add(1)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := acquired.Load(); n != 1 {
+		t.Fatalf("expected the expired lock to be taken over once, got %d", n)
+	}
+}
+
+// fakeDynamoDBLockTable serves the DynamoDB requests of dynamodbLock from memory. Like DynamoDB, it rejects
+// expressions that use the reserved word OWNER as an attribute name.
+type fakeDynamoDBLockTable struct {
+	mu    sync.Mutex
+	items map[string]map[string]string
+}
+
+var reservedOwner = regexp.MustCompile(`(^|[^#:\w])(?i:owner)\b`)
+
+func (f *fakeDynamoDBLockTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Item                      map[string]map[string]string
+		Key                       map[string]map[string]string
+		ConditionExpression       string
+		UpdateExpression          string
+		ExpressionAttributeNames  map[string]string
+		ExpressionAttributeValues map[string]map[string]string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	fail := func(errorType string) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, `{"__type":"com.amazonaws.dynamodb.v20120810#%s","message":"%s"}`, errorType, errorType)
+	}
+
+	for _, expression := range []string{input.ConditionExpression, input.UpdateExpression} {
+		if reservedOwner.MatchString(expression) {
+			fail("ValidationException")
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ownerMatches := func(id string) bool {
+		item, ok := f.items[id]
+		return ok && input.ExpressionAttributeNames["#owner"] == "Owner" && item["Owner"] == input.ExpressionAttributeValues[":owner"]["S"]
+	}
+
+	switch r.Header.Get("X-Amz-Target") {
+	case "DynamoDB_20120810.PutItem":
+		id := input.Item["LockID"]["S"]
+		if item, ok := f.items[id]; ok && item["Expires"] >= input.ExpressionAttributeValues[":now"]["N"] {
+			fail("ConditionalCheckFailedException")
+			return
+		}
+		f.items[id] = map[string]string{"Owner": input.Item["Owner"]["S"], "Expires": input.Item["Expires"]["N"]}
+	case "DynamoDB_20120810.UpdateItem":
+		id := input.Key["LockID"]["S"]
+		if !ownerMatches(id) {
+			fail("ConditionalCheckFailedException")
+			return
+		}
+		f.items[id]["Expires"] = input.ExpressionAttributeValues[":expires"]["N"]
+	case "DynamoDB_20120810.DeleteItem":
+		id := input.Key["LockID"]["S"]
+		if !ownerMatches(id) {
+			fail("ConditionalCheckFailedException")
+			return
+		}
+		delete(f.items, id)
+	default:
+		fail("UnknownOperationException")
+		return
+	}
+
+	_, _ = w.Write([]byte(`{}`))
+}
+
+func (f *fakeDynamoDBLockTable) expires(id string) (int64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	item, ok := f.items[id]
+	if !ok {
+		return 0, false
+	}
+	expires, _ := strconv.ParseInt(item["Expires"], 10, 64)
+	return expires, true
+}
+
+func TestDynamoDBLock(t *testing.T) {
+	table := &fakeDynamoDBLockTable{items: map[string]map[string]string{}}
+	server := httptest.NewServer(table)
+	defer server.Close()
+
+	lock := &dynamodbLock{
+		client: dynamodb.New(dynamodb.Options{
+			Region:       "us-east-1",
+			BaseEndpoint: aws.String(server.URL),
+			Credentials:  aws.AnonymousCredentials{},
+		}),
+		table:       "terraform-locks",
+		id:          "account-factory",
+		slots:       1,
+		ttl:         3 * time.Second,
+		waitTimeout: time.Second,
+	}
+
+	release, err := lock.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error acquiring lock: %v", err)
+	}
+
+	acquiredExpires, ok := table.expires("account-factory")
+	if !ok {
+		t.Fatalf("expected lock item to be written")
+	}
+
+	if _, err := lock.acquire(context.Background()); err == nil {
+		t.Fatalf("expected error acquiring a held lock")
+	}
+
+	// The lock is renewed every second, so its expiry moves on while it is held.
+	time.Sleep(2500 * time.Millisecond)
+	if expires, _ := table.expires("account-factory"); expires <= acquiredExpires {
+		t.Fatalf("expected lock to be renewed, expiry is still %d", expires)
+	}
+
+	release()
+	if _, ok := table.expires("account-factory"); ok {
+		t.Fatalf("expected lock item to be removed")
+	}
+
+	release, err = lock.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error acquiring a released lock: %v", err)
+	}
+	release()
+}
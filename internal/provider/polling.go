@@ -0,0 +1,222 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// pollingConfig describes how long running operations are polled.
+type pollingConfig struct {
+	// initialDelay is waited before the first poll.
+	initialDelay time.Duration
+	// interval is the delay between the first polls, it grows by backoffMultiplier up to maxInterval.
+	interval          time.Duration
+	maxInterval       time.Duration
+	backoffMultiplier float64
+	// jitter randomises each delay by up to this fraction.
+	jitter float64
+	// minConsecutiveSuccesses is the number of successive polls that have to report success.
+	minConsecutiveSuccesses int
+}
+
+// defaultPollingConfig polls every 5 seconds. Only Account Factory operations can be tuned with the
+// provisioning_polling block, all other operations are polled with these defaults.
+var defaultPollingConfig = pollingConfig{
+	initialDelay:            0,
+	interval:                5 * time.Second,
+	maxInterval:             5 * time.Second,
+	backoffMultiplier:       2,
+	jitter:                  0,
+	minConsecutiveSuccesses: 1,
+}
+
+// pollingSchema returns the schema of the provisioning_polling block. Settings that are not
+// configured on a resource are inherited from the provider.
+func pollingSchema(description string, isProvider bool) *schema.Schema {
+	defaultDescription := func(value string) string {
+		if isProvider {
+			return " Defaults to `" + value + "`."
+		}
+		return " Defaults to the provider setting."
+	}
+
+	return &schema.Schema{
+		Description: description,
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"initial_delay": {
+					Description:  "Time to wait before the status is polled for the first time, given as a string like `10m`." + defaultDescription("0s"),
+					Type:         schema.TypeString,
+					Optional:     true,
+					ValidateFunc: validateDuration(0, 2*time.Hour),
+				},
+				"interval": {
+					Description:  "Time between the first polls, given as a string like `5s`." + defaultDescription("5s"),
+					Type:         schema.TypeString,
+					Optional:     true,
+					ValidateFunc: validateDuration(time.Second, time.Hour),
+				},
+				"max_interval": {
+					Description:  "Maximum time between two polls, given as a string like `1m`." + defaultDescription("5s"),
+					Type:         schema.TypeString,
+					Optional:     true,
+					ValidateFunc: validateDuration(time.Second, time.Hour),
+				},
+				"backoff_multiplier": {
+					Description:  "Factor by which the time between two polls grows until it reaches `max_interval`." + defaultDescription("2"),
+					Type:         schema.TypeFloat,
+					Optional:     true,
+					ValidateFunc: validation.FloatBetween(1, 10),
+				},
+				"jitter": {
+					Description:  "Fraction by which the time between two polls is randomised, e.g. `0.2` for up to 20%." + defaultDescription("0"),
+					Type:         schema.TypeFloat,
+					Optional:     true,
+					ValidateFunc: validation.FloatBetween(0, 1),
+				},
+				"min_consecutive_successes": {
+					Description:  "Number of consecutive polls that have to report a successful operation before it is considered done." + defaultDescription("1"),
+					Type:         schema.TypeInt,
+					Optional:     true,
+					ValidateFunc: validation.IntBetween(1, 10),
+				},
+			},
+		},
+	}
+}
+
+// expandPollingConfig applies the settings of a provisioning_polling block on top of the given defaults.
+// raw is the configured block, see rawPollingConfig.
+func expandPollingConfig(l []interface{}, raw cty.Value, defaults pollingConfig) pollingConfig {
+	config := defaults
+	if len(l) == 0 || l[0] == nil {
+		return config
+	}
+	m := l[0].(map[string]interface{})
+
+	// the durations have already been validated by the schema
+	if v, ok := m["initial_delay"].(string); ok && v != "" {
+		config.initialDelay, _ = time.ParseDuration(v)
+	}
+	if v, ok := m["interval"].(string); ok && v != "" {
+		config.interval, _ = time.ParseDuration(v)
+	}
+	if v, ok := m["max_interval"].(string); ok && v != "" {
+		config.maxInterval, _ = time.ParseDuration(v)
+	}
+	if v, ok := m["backoff_multiplier"].(float64); ok && v != 0 {
+		config.backoffMultiplier = v
+	}
+	// a jitter of 0 disables the jitter of the defaults, so it is only ignored if it is not configured
+	if v, ok := m["jitter"].(float64); ok && (v != 0 || (!raw.IsNull() && raw.IsKnown() && !raw.GetAttr("jitter").IsNull())) {
+		config.jitter = v
+	}
+	if v, ok := m["min_consecutive_successes"].(int); ok && v != 0 {
+		config.minConsecutiveSuccesses = v
+	}
+
+	return config
+}
+
+// rawPollingConfig returns the configured provisioning_polling block, which tells settings that are
+// explicitly set to 0 apart from unset ones. The value is null if the block is not configured.
+func rawPollingConfig(d *schema.ResourceData) cty.Value {
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return cty.NullVal(cty.DynamicPseudoType)
+	}
+
+	block := config.GetAttr("provisioning_polling")
+	if block.IsNull() || !block.IsKnown() || block.LengthInt() == 0 {
+		return cty.NullVal(cty.DynamicPseudoType)
+	}
+
+	return block.Index(cty.NumberIntVal(0))
+}
+
+// poller keeps track of the delay between the polls of a single operation.
+type poller struct {
+	config   pollingConfig
+	interval time.Duration
+	polls    int
+}
+
+func newPoller(config pollingConfig) *poller {
+	return &poller{
+		config:   config,
+		interval: config.interval,
+	}
+}
+
+// nextDelay returns the time to wait before the next poll.
+func (p *poller) nextDelay() time.Duration {
+	defer func() { p.polls++ }()
+
+	if p.polls == 0 {
+		return p.config.initialDelay
+	}
+
+	delay := p.interval
+	if p.config.jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + p.config.jitter*(2*rand.Float64()-1)))
+	}
+	p.interval = min(time.Duration(float64(p.interval)*p.config.backoffMultiplier), max(p.config.maxInterval, p.config.interval))
+
+	return delay
+}
+
+// wait blocks until the next poll is due. It returns false if the context is done before.
+func (p *poller) wait(ctx context.Context) bool {
+	delay := p.nextDelay()
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// waitForOperation polls a long running operation until poll reports it as succeeded often enough in
+// a row. An error returned by poll, e.g. because the operation failed, stops waiting. The description
+// names the operation in the timeout error.
+func waitForOperation(ctx context.Context, description string, polling pollingConfig, poll func() (bool, error)) error {
+	poller := newPoller(polling)
+	successes := 0
+
+	for {
+		// Wait before checking the status, but respect context cancellation (timeout reached)
+		if !poller.wait(ctx) {
+			return fmt.Errorf("timeout reached while waiting for %s: %v", description, ctx.Err())
+		}
+
+		succeeded, err := poll()
+		if err != nil {
+			return err
+		}
+
+		if !succeeded {
+			successes = 0
+			continue
+		}
+
+		successes++
+		if successes >= polling.minConsecutiveSuccesses {
+			return nil
+		}
+	}
+}
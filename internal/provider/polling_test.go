@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+)
+
+func TestPollerBackoff(t *testing.T) {
+	p := newPoller(pollingConfig{
+		initialDelay:      time.Minute,
+		interval:          time.Second,
+		maxInterval:       5 * time.Second,
+		backoffMultiplier: 2,
+	})
+
+	expected := []time.Duration{time.Minute, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		if got := p.nextDelay(); got != want {
+			t.Fatalf("poll %d: expected delay %s, got %s", i, want, got)
+		}
+	}
+}
+
+func TestPollerJitter(t *testing.T) {
+	p := newPoller(pollingConfig{
+		interval:          10 * time.Second,
+		maxInterval:       10 * time.Second,
+		backoffMultiplier: 1,
+		jitter:            0.2,
+	})
+	p.nextDelay()
+
+	for i := 0; i < 100; i++ {
+		if got := p.nextDelay(); got < 8*time.Second || got > 12*time.Second {
+			t.Fatalf("expected delay between 8s and 12s, got %s", got)
+		}
+	}
+}
+
+func TestExpandPollingConfig(t *testing.T) {
+	got := expandPollingConfig([]interface{}{
+		map[string]interface{}{
+			"initial_delay":             "10m",
+			"interval":                  "",
+			"max_interval":              "1m",
+			"backoff_multiplier":        0.0,
+			"jitter":                    0.2,
+			"min_consecutive_successes": 0,
+		},
+	}, cty.NullVal(cty.DynamicPseudoType), defaultPollingConfig)
+
+	want := defaultPollingConfig
+	want.initialDelay = 10 * time.Minute
+	want.maxInterval = time.Minute
+	want.jitter = 0.2
+
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestExpandPollingConfigJitterDisabled(t *testing.T) {
+	defaults := defaultPollingConfig
+	defaults.jitter = 0.2
+
+	l := []interface{}{
+		map[string]interface{}{
+			"jitter": 0.0,
+		},
+	}
+
+	if got := expandPollingConfig(l, cty.NullVal(cty.DynamicPseudoType), defaults); got.jitter != 0.2 {
+		t.Errorf("expected the default jitter 0.2 if it is not configured, got %v", got.jitter)
+	}
+
+	raw := cty.ObjectVal(map[string]cty.Value{
+		"jitter": cty.NumberFloatVal(0),
+	})
+	if got := expandPollingConfig(l, raw, defaults); got.jitter != 0 {
+		t.Errorf("expected a configured jitter of 0 to disable the jitter, got %v", got.jitter)
+	}
+}
+
+func TestWaitForOperation(t *testing.T) {
+	// in progress, succeeded, in progress, succeeded twice
+	results := []bool{false, true, false, true, true, true}
+	polls := 0
+
+	err := waitForOperation(context.Background(), "test", pollingConfig{minConsecutiveSuccesses: 2}, func() (bool, error) {
+		polls++
+		return results[polls-1], nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if polls != 5 {
+		t.Fatalf("expected 5 polls, got %d", polls)
+	}
+}
+
+func TestWaitForOperationError(t *testing.T) {
+	failed := errors.New("failed")
+
+	err := waitForOperation(context.Background(), "test", pollingConfig{minConsecutiveSuccesses: 1}, func() (bool, error) {
+		return false, failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected %v, got %v", failed, err)
+	}
+}
+
+func TestWaitForOperationTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := waitForOperation(ctx, "test", pollingConfig{minConsecutiveSuccesses: 1}, func() (bool, error) {
+		t.Fatalf("unexpected poll after the context was cancelled")
+		return false, nil
+	})
+	if err == nil {
+		t.Fatalf("expected timeout error")
+	}
+}
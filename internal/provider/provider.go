@@ -2,12 +2,25 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/middleware"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	stsTypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
 	smithymw "github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func init() {
@@ -73,16 +86,334 @@ func New(version string) func() *schema.Provider {
 					Optional:    true,
 					Default:     25,
 				},
+				"assume_role": {
+					Description: "Configuration block for assuming an IAM role in the Control Tower management account. The role is assumed with the credentials configured above.",
+					Type:        schema.TypeList,
+					Optional:    true,
+					MaxItems:    1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"role_arn": {
+								Description:  "ARN of the IAM role to assume.",
+								Type:         schema.TypeString,
+								Required:     true,
+								ValidateFunc: validateARN,
+							},
+							"session_name": {
+								Description: "Session name to use when assuming the role.",
+								Type:        schema.TypeString,
+								Optional:    true,
+							},
+							"external_id": {
+								Description:  "External identifier to use when assuming the role.",
+								Type:         schema.TypeString,
+								Optional:     true,
+								ValidateFunc: validation.StringLenBetween(2, 1224),
+							},
+							"duration": {
+								Description:  "Duration the assumed role session is valid for, given as a string like `1h` or `15m`. Valid range is between `15m` and `12h`.",
+								Type:         schema.TypeString,
+								Optional:     true,
+								ValidateFunc: validateDuration(15*time.Minute, 12*time.Hour),
+							},
+							"policy": {
+								Description:  "IAM policy JSON that further restricts the permissions of the assumed role session.",
+								Type:         schema.TypeString,
+								Optional:     true,
+								ValidateFunc: validation.StringIsJSON,
+							},
+							"policy_arns": {
+								Description: "ARNs of IAM managed policies that further restrict the permissions of the assumed role session.",
+								Type:        schema.TypeSet,
+								Optional:    true,
+								Elem: &schema.Schema{
+									Type:         schema.TypeString,
+									ValidateFunc: validateARN,
+								},
+							},
+							"tags": {
+								Description: "Session tags to pass when assuming the role.",
+								Type:        schema.TypeMap,
+								Optional:    true,
+								Elem:        &schema.Schema{Type: schema.TypeString},
+							},
+							"transitive_tag_keys": {
+								Description: "Keys of the session tags that are passed on to subsequent sessions in a role chain.",
+								Type:        schema.TypeSet,
+								Optional:    true,
+								Elem:        &schema.Schema{Type: schema.TypeString},
+							},
+						},
+					},
+				},
+
+				"assume_role_with_web_identity": {
+					Description: "Configuration block for assuming an IAM role using a web identity token, e.g. an OIDC token issued to a CI runner. If `assume_role` is configured as well, it is assumed with the resulting credentials.",
+					Type:        schema.TypeList,
+					Optional:    true,
+					MaxItems:    1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"role_arn": {
+								Description:  "ARN of the IAM role to assume.",
+								Type:         schema.TypeString,
+								Required:     true,
+								ValidateFunc: validateARN,
+							},
+							"web_identity_token": {
+								Description: "Value of the web identity token. Conflicts with `web_identity_token_file`.",
+								Type:        schema.TypeString,
+								Optional:    true,
+								Sensitive:   true,
+							},
+							"web_identity_token_file": {
+								Description: "Path to a file containing the web identity token. Conflicts with `web_identity_token`.",
+								Type:        schema.TypeString,
+								Optional:    true,
+							},
+							"session_name": {
+								Description: "Session name to use when assuming the role.",
+								Type:        schema.TypeString,
+								Optional:    true,
+							},
+							"duration": {
+								Description:  "Duration the assumed role session is valid for, given as a string like `1h` or `15m`. Valid range is between `15m` and `12h`.",
+								Type:         schema.TypeString,
+								Optional:     true,
+								ValidateFunc: validateDuration(15*time.Minute, 12*time.Hour),
+							},
+						},
+					},
+				},
+
+				"endpoints": {
+					Description: "Configuration block for overriding the endpoints of the AWS services used by the provider, e.g. to use VPC or FIPS endpoints or a local emulator.",
+					Type:        schema.TypeList,
+					Optional:    true,
+					MaxItems:    1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"controltower":   endpointSchema("AWS Control Tower"),
+							"dynamodb":       endpointSchema("Amazon DynamoDB"),
+							"identitystore":  endpointSchema("IAM Identity Store"),
+							"organizations":  endpointSchema("AWS Organizations"),
+							"servicecatalog": endpointSchema("AWS Service Catalog"),
+							"ssoadmin":       endpointSchema("IAM Identity Center (SSO Admin)"),
+							"sts":            endpointSchema("AWS STS"),
+						},
+					},
+				},
+
+				"default_tags": {
+					Description: "Configuration block with tags that are added to every account managed by the provider. Tags configured on the resource take precedence.",
+					Type:        schema.TypeList,
+					Optional:    true,
+					MaxItems:    1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"tags": {
+								Description: "Key-value map of tags to add to every account.",
+								Type:        schema.TypeMap,
+								Optional:    true,
+								Elem:        &schema.Schema{Type: schema.TypeString},
+							},
+						},
+					},
+				},
+
+				"ignore_tags": {
+					Description: "Configuration block with tags that are managed outside of Terraform. Matching tags are neither read into the state nor removed from the accounts.",
+					Type:        schema.TypeList,
+					Optional:    true,
+					MaxItems:    1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"keys": {
+								Description: "Tag keys to ignore.",
+								Type:        schema.TypeSet,
+								Optional:    true,
+								Elem:        &schema.Schema{Type: schema.TypeString},
+							},
+							"key_prefixes": {
+								Description: "Tag key prefixes to ignore.",
+								Type:        schema.TypeSet,
+								Optional:    true,
+								Elem:        &schema.Schema{Type: schema.TypeString},
+							},
+						},
+					},
+				},
+
+				"allowed_account_ids": {
+					Description:   "List of allowed AWS account IDs to prevent you from mistakenly using an incorrect one. Conflicts with `forbidden_account_ids`.",
+					Type:          schema.TypeSet,
+					Optional:      true,
+					ConflictsWith: []string{"forbidden_account_ids"},
+					Elem: &schema.Schema{
+						Type:         schema.TypeString,
+						ValidateFunc: validateAccountID,
+					},
+				},
+
+				"forbidden_account_ids": {
+					Description:   "List of forbidden AWS account IDs to prevent you from mistakenly using an incorrect one. Conflicts with `allowed_account_ids`.",
+					Type:          schema.TypeSet,
+					Optional:      true,
+					ConflictsWith: []string{"allowed_account_ids"},
+					Elem: &schema.Schema{
+						Type:         schema.TypeString,
+						ValidateFunc: validateAccountID,
+					},
+				},
+
+				"skip_management_account_check": {
+					Description: "Skip checking that the configured credentials belong to the organization management account or a delegated Service Catalog administrator. The check requires the `sts:GetCallerIdentity`, `organizations:DescribeOrganization` and `organizations:ListDelegatedAdministrators` permissions. Useful when running against an emulator. Defaults to `false`.",
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+				},
+
+				"user_agent": {
+					Description: "Product details to append to the User-Agent string sent in all AWS API calls. The value of the `TF_APPEND_USER_AGENT` environment variable is appended as well.",
+					Type:        schema.TypeList,
+					Optional:    true,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"product_name": {
+								Description:  "Name of the product.",
+								Type:         schema.TypeString,
+								Required:     true,
+								ValidateFunc: validation.StringMatch(regexp.MustCompile(`^[^\s/()]+$`), "must not contain whitespace, slashes or parentheses"),
+							},
+							"product_version": {
+								Description:  "Version of the product.",
+								Type:         schema.TypeString,
+								Optional:     true,
+								ValidateFunc: validation.StringMatch(regexp.MustCompile(`^[^\s/()]+$`), "must not contain whitespace, slashes or parentheses"),
+							},
+							"comment": {
+								Description:  "Comment describing any additional product details.",
+								Type:         schema.TypeString,
+								Optional:     true,
+								ValidateFunc: validation.StringMatch(regexp.MustCompile(`^[^()]*$`), "must not contain parentheses"),
+							},
+						},
+					},
+				},
+
+				"account_factory_product_id": {
+					Description:   "ID of the Account Factory product in Service Catalog. If not set, the product is searched by `account_factory_product_name`. Conflicts with `account_factory_product_name`.",
+					Type:          schema.TypeString,
+					Optional:      true,
+					ConflictsWith: []string{"account_factory_product_name"},
+					ValidateFunc:  validation.StringMatch(regexp.MustCompile(`^prod-[a-z0-9]+$`), "must be a Service Catalog product ID"),
+				},
+
+				"account_factory_product_name": {
+					Description:   "Name of the Account Factory product in Service Catalog. Defaults to `AWS Control Tower Account Factory`. Conflicts with `account_factory_product_id`.",
+					Type:          schema.TypeString,
+					Optional:      true,
+					ConflictsWith: []string{"account_factory_product_id"},
+				},
+
+				"account_factory_product_name_match": {
+					Description:  "How the Account Factory product name is matched. With `full_text` the Service Catalog full-text search must return exactly one product, with `exact` exactly one product with the given name must exist. Defaults to `full_text`.",
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      "full_text",
+					ValidateFunc: validation.StringInSlice([]string{"full_text", "exact"}, false),
+				},
+
+				"sso_instance_arn": {
+					Description:  "ARN of the IAM Identity Center instance used for account assignments. Required if more than one instance exists and `identity_store_id` is not set.",
+					Type:         schema.TypeString,
+					Optional:     true,
+					ValidateFunc: validateARN,
+				},
+
+				"identity_store_id": {
+					Description:  "ID of the Identity Store of the IAM Identity Center instance used for account assignments. Required if more than one instance exists and `sso_instance_arn` is not set.",
+					Type:         schema.TypeString,
+					Optional:     true,
+					ValidateFunc: validation.StringMatch(regexp.MustCompile(`^d-[0-9a-f]{10}$|^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`), "must be an Identity Store ID"),
+				},
+
+				"max_concurrent_provisioning_operations": {
+					Description:  "Maximum number of Account Factory operations (create, update and delete of accounts) that run at the same time. Control Tower limits the number of concurrent operations, so check its current quota before raising this value. Defaults to `1`.",
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      1,
+					ValidateFunc: validation.IntAtLeast(1),
+				},
+
+				"provisioning_lock": {
+					Description: "Configuration block for the lock that limits concurrent Account Factory operations to `max_concurrent_provisioning_operations`. By default operations are only limited within a single Terraform run.",
+					Type:        schema.TypeList,
+					Optional:    true,
+					MaxItems:    1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"type": {
+								Description:  "Type of the lock. Valid values are `memory` (within one Terraform run), `file` (across processes on a shared file system) and `dynamodb` (across machines).",
+								Type:         schema.TypeString,
+								Optional:     true,
+								Default:      "memory",
+								ValidateFunc: validation.StringInSlice([]string{"memory", "file", "dynamodb"}, false),
+							},
+							"file_path": {
+								Description: "Path of the lock file. Required for the `file` lock.",
+								Type:        schema.TypeString,
+								Optional:    true,
+							},
+							"dynamodb_table": {
+								Description: "Name of the DynamoDB table used for the `dynamodb` lock. The table must have a partition key named `LockID` of type string, so an existing Terraform state lock table can be reused.",
+								Type:        schema.TypeString,
+								Optional:    true,
+							},
+							"lock_id": {
+								Description: "Identifier of the lock, e.g. to use separate locks per organization. Defaults to `terraform-provider-controltower/account-factory`.",
+								Type:        schema.TypeString,
+								Optional:    true,
+								Default:     defaultProvisioningLockId,
+							},
+							"ttl": {
+								Description:  "Time after which a lock of a crashed process expires, given as a string like `1h`. Held locks are renewed every third of the TTL, so it does not limit the duration of an operation. Defaults to `1h`.",
+								Type:         schema.TypeString,
+								Optional:     true,
+								Default:      defaultProvisioningLockTTL.String(),
+								ValidateFunc: validateDuration(time.Minute, 24*time.Hour),
+							},
+							"wait_timeout": {
+								Description:  "Maximum time to wait for the lock, given as a string like `30m`. Defaults to the timeout of the operation.",
+								Type:         schema.TypeString,
+								Optional:     true,
+								ValidateFunc: validateDuration(time.Second, 24*time.Hour),
+							},
+						},
+					},
+				},
+
+				"provisioning_polling": pollingSchema("Configuration block for polling Account Factory operations, which can take 20 to 45 minutes. By default the status is polled every 5 seconds.", true),
+
 				"provider_version": {
-					Description: "The version of the provider, just used for logging.",
+					Description: "The version of the provider, sent as part of the User-Agent of all AWS API calls.",
 					Type:        schema.TypeString,
 					Optional:    true,
 					Default:     version,
 				},
 			},
-			DataSourcesMap: map[string]*schema.Resource{},
+			DataSourcesMap: map[string]*schema.Resource{
+				"controltower_account_factory_product": dataSourceAccountFactoryProduct(),
+				"controltower_aws_account":             dataSourceAWSAccount(),
+				"controltower_aws_accounts":            dataSourceAWSAccounts(),
+			},
 			ResourcesMap: map[string]*schema.Resource{
-				"controltower_aws_account": resourceAWSAccount(),
+				"controltower_account_assignment":  resourceAccountAssignment(),
+				"controltower_aws_account":         resourceAWSAccount(),
+				"controltower_control":             resourceControl(),
+				"controltower_enabled_baseline":    resourceEnabledBaseline(),
+				"controltower_landing_zone":        resourceLandingZone(),
+				"controltower_organizational_unit": resourceOrganizationalUnit(),
 			},
 			ConfigureContextFunc: configureProvider,
 		}
@@ -122,15 +453,339 @@ func configureProvider(ctx context.Context, d *schema.ResourceData) (interface{}
 		options = append(options, config.WithSharedConfigProfile(profile))
 	}
 
+	// Identify the provider and any configured products in the user agent
+	apiOptions := []func(*smithymw.Stack) error{
+		middleware.AddUserAgentKeyValue("terraform-provider-controltower", d.Get("provider_version").(string)),
+	}
+	if userAgent := expandUserAgentProducts(d.Get("user_agent").([]interface{})); userAgent != "" {
+		apiOptions = append(apiOptions, withUserAgentAppended(userAgent))
+	}
+	if v := strings.TrimSpace(os.Getenv("TF_APPEND_USER_AGENT")); v != "" {
+		apiOptions = append(apiOptions, withUserAgentAppended(v))
+	}
+	options = append(options, config.WithAPIOptions(apiOptions))
+
 	// Load the default AWS config
 	cfg, err := config.LoadDefaultConfig(ctx, options...)
-	config.WithAPIOptions([]func(*smithymw.Stack) error{
-		middleware.AddUserAgentKeyValue("terraform-provider-controltower", d.Get("provider_version").(string)),
-	})
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
 
-	// Return the configured AWS SDK config
-	return cfg, nil
+	client := &providerClient{
+		config:      cfg,
+		endpoints:   map[string]string{},
+		defaultTags: map[string]string{},
+		accountFactoryProduct: accountFactoryProduct{
+			id:        d.Get("account_factory_product_id").(string),
+			name:      d.Get("account_factory_product_name").(string),
+			exactName: d.Get("account_factory_product_name_match").(string) == "exact",
+		},
+		ssoInstance: ssoInstance{
+			instanceArn:     d.Get("sso_instance_arn").(string),
+			identityStoreId: d.Get("identity_store_id").(string),
+		},
+	}
+
+	if v, ok := d.GetOk("endpoints"); ok && len(v.([]interface{})) > 0 && v.([]interface{})[0] != nil {
+		for service, endpoint := range v.([]interface{})[0].(map[string]interface{}) {
+			client.endpoints[service] = endpoint.(string)
+		}
+	}
+
+	if v, ok := d.GetOk("default_tags"); ok && len(v.([]interface{})) > 0 && v.([]interface{})[0] != nil {
+		for key, value := range v.([]interface{})[0].(map[string]interface{})["tags"].(map[string]interface{}) {
+			client.defaultTags[key] = value.(string)
+		}
+	}
+
+	if v, ok := d.GetOk("ignore_tags"); ok && len(v.([]interface{})) > 0 && v.([]interface{})[0] != nil {
+		ignoreTags := v.([]interface{})[0].(map[string]interface{})
+		client.ignoreTags = &ignoreTagsConfig{
+			keys: map[string]struct{}{},
+		}
+
+		for _, key := range ignoreTags["keys"].(*schema.Set).List() {
+			client.ignoreTags.keys[key.(string)] = struct{}{}
+		}
+		for _, prefix := range ignoreTags["key_prefixes"].(*schema.Set).List() {
+			client.ignoreTags.keyPrefixes = append(client.ignoreTags.keyPrefixes, prefix.(string))
+		}
+	}
+
+	client.polling = expandPollingConfig(d.Get("provisioning_polling").([]interface{}), rawPollingConfig(d), defaultPollingConfig)
+
+	// Exchange the web identity token for role credentials
+	if v, ok := d.GetOk("assume_role_with_web_identity"); ok && len(v.([]interface{})) > 0 && v.([]interface{})[0] != nil {
+		webIdentity := v.([]interface{})[0].(map[string]interface{})
+		provider, err := expandWebIdentityRoleProvider(client.stsClient(), webIdentity)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		client.config.Credentials = aws.NewCredentialsCache(provider)
+
+		if _, err := client.config.Credentials.Retrieve(ctx); err != nil {
+			return nil, diag.Errorf("error assuming role %s with web identity: %v", webIdentity["role_arn"], err)
+		}
+	}
+
+	// Assume the configured role on top of the base credentials
+	if v, ok := d.GetOk("assume_role"); ok && len(v.([]interface{})) > 0 && v.([]interface{})[0] != nil {
+		assumeRole := v.([]interface{})[0].(map[string]interface{})
+		client.config.Credentials = aws.NewCredentialsCache(expandAssumeRoleProvider(client.stsClient(), assumeRole))
+
+		if _, err := client.config.Credentials.Retrieve(ctx); err != nil {
+			return nil, diag.Errorf("error assuming role %s: %v", assumeRole["role_arn"], err)
+		}
+	}
+
+	client.connect()
+
+	// The lock is built with the final credentials, as the DynamoDB lock table lives in the management account.
+	provisioningLock, err := expandProvisioningLock(client, d.Get("provisioning_lock").([]interface{}), d.Get("max_concurrent_provisioning_operations").(int))
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	client.provisioningLock = provisioningLock
+
+	if diags := validateCallerAccount(ctx, d, client); diags.HasError() {
+		return nil, diags
+	}
+
+	// Return the configured provider client
+	return client, nil
+}
+
+// validateCallerAccount makes sure the provider runs in the intended account before any resource is touched.
+func validateCallerAccount(ctx context.Context, d *schema.ResourceData, client *providerClient) diag.Diagnostics {
+	allowedAccountIds := d.Get("allowed_account_ids").(*schema.Set)
+	forbiddenAccountIds := d.Get("forbidden_account_ids").(*schema.Set)
+	skipManagementAccountCheck := d.Get("skip_management_account_check").(bool)
+
+	if allowedAccountIds.Len() == 0 && forbiddenAccountIds.Len() == 0 && skipManagementAccountCheck {
+		return nil
+	}
+
+	identity, err := client.stsClient().GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return diag.Errorf("error retrieving caller identity: %v", err)
+	}
+	accountId := aws.ToString(identity.Account)
+	client.accountId = accountId
+
+	if allowedAccountIds.Len() > 0 && !allowedAccountIds.Contains(accountId) {
+		return diag.Errorf("AWS account ID not allowed: %s", accountId)
+	}
+	if forbiddenAccountIds.Contains(accountId) {
+		return diag.Errorf("AWS account ID not allowed: %s", accountId)
+	}
+
+	if skipManagementAccountCheck {
+		return nil
+	}
+
+	organizationsconn := client.organizationsconn
+
+	organization, err := organizationsconn.DescribeOrganization(ctx, &organizations.DescribeOrganizationInput{})
+	if err != nil {
+		return diag.Errorf("error describing organization of account %s: %v", accountId, err)
+	}
+	managementAccountId := aws.ToString(organization.Organization.MasterAccountId)
+	if accountId == managementAccountId {
+		return nil
+	}
+
+	paginator := organizations.NewListDelegatedAdministratorsPaginator(organizationsconn, &organizations.ListDelegatedAdministratorsInput{
+		ServicePrincipal: aws.String("servicecatalog.amazonaws.com"),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return diag.Errorf("account %s is not the organization management account (%s) and its delegated administrator status could not be verified: %v", accountId, managementAccountId, err)
+		}
+
+		for _, admin := range output.DelegatedAdministrators {
+			if aws.ToString(admin.Id) == accountId {
+				return nil
+			}
+		}
+	}
+
+	return diag.Errorf("account %s is neither the organization management account (%s) nor a delegated Service Catalog administrator", accountId, managementAccountId)
+}
+
+func endpointSchema(service string) *schema.Schema {
+	return &schema.Schema{
+		Description:  fmt.Sprintf("Custom endpoint URL for %s.", service),
+		Type:         schema.TypeString,
+		Optional:     true,
+		ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+	}
+}
+
+// expandAssumeRoleProvider builds a credentials provider from the assume_role block.
+func expandAssumeRoleProvider(client *sts.Client, m map[string]interface{}) *stscreds.AssumeRoleProvider {
+	return stscreds.NewAssumeRoleProvider(client, m["role_arn"].(string), func(o *stscreds.AssumeRoleOptions) {
+		if v, ok := m["session_name"].(string); ok && v != "" {
+			o.RoleSessionName = v
+		}
+		if v, ok := m["external_id"].(string); ok && v != "" {
+			o.ExternalID = aws.String(v)
+		}
+		if v, ok := m["duration"].(string); ok && v != "" {
+			// the value has already been validated by the schema
+			o.Duration, _ = time.ParseDuration(v)
+		}
+		if v, ok := m["policy"].(string); ok && v != "" {
+			o.Policy = aws.String(v)
+		}
+		if v, ok := m["policy_arns"].(*schema.Set); ok {
+			for _, arn := range v.List() {
+				o.PolicyARNs = append(o.PolicyARNs, stsTypes.PolicyDescriptorType{
+					Arn: aws.String(arn.(string)),
+				})
+			}
+		}
+		if v, ok := m["tags"].(map[string]interface{}); ok {
+			for key, value := range v {
+				o.Tags = append(o.Tags, stsTypes.Tag{
+					Key:   aws.String(key),
+					Value: aws.String(value.(string)),
+				})
+			}
+		}
+		if v, ok := m["transitive_tag_keys"].(*schema.Set); ok {
+			for _, key := range v.List() {
+				o.TransitiveTagKeys = append(o.TransitiveTagKeys, key.(string))
+			}
+		}
+	})
+}
+
+// webIdentityToken provides a web identity token that was configured inline.
+type webIdentityToken string
+
+func (t webIdentityToken) GetIdentityToken() ([]byte, error) {
+	return []byte(t), nil
+}
+
+// expandWebIdentityRoleProvider builds a credentials provider from the assume_role_with_web_identity block.
+func expandWebIdentityRoleProvider(client *sts.Client, m map[string]interface{}) (*stscreds.WebIdentityRoleProvider, error) {
+	token := m["web_identity_token"].(string)
+	tokenFile := m["web_identity_token_file"].(string)
+
+	var tokenRetriever stscreds.IdentityTokenRetriever
+	switch {
+	case token != "" && tokenFile != "":
+		return nil, fmt.Errorf("only one of web_identity_token and web_identity_token_file can be set")
+	case token != "":
+		tokenRetriever = webIdentityToken(token)
+	case tokenFile != "":
+		tokenRetriever = stscreds.IdentityTokenFile(tokenFile)
+	default:
+		return nil, fmt.Errorf("one of web_identity_token or web_identity_token_file must be set")
+	}
+
+	return stscreds.NewWebIdentityRoleProvider(client, m["role_arn"].(string), tokenRetriever, func(o *stscreds.WebIdentityRoleOptions) {
+		if v, ok := m["session_name"].(string); ok && v != "" {
+			o.RoleSessionName = v
+		}
+		if v, ok := m["duration"].(string); ok && v != "" {
+			// the value has already been validated by the schema
+			o.Duration, _ = time.ParseDuration(v)
+		}
+	}), nil
+}
+
+// expandUserAgentProducts formats the user_agent blocks as "name/version (comment)" products.
+func expandUserAgentProducts(l []interface{}) string {
+	products := make([]string, 0, len(l))
+
+	for _, v := range l {
+		if v == nil {
+			continue
+		}
+		m := v.(map[string]interface{})
+
+		product := m["product_name"].(string)
+		if version := m["product_version"].(string); version != "" {
+			product += "/" + version
+		}
+		if comment := m["comment"].(string); comment != "" {
+			product += " (" + comment + ")"
+		}
+		products = append(products, product)
+	}
+
+	return strings.Join(products, " ")
+}
+
+// userAgentAppender appends a raw value to the User-Agent header. Unlike the SDK helpers
+// it keeps slashes, spaces and parentheses intact.
+type userAgentAppender string
+
+func (userAgentAppender) ID() string {
+	return "ControlTowerUserAgentAppender"
+}
+
+func (a userAgentAppender) HandleBuild(ctx context.Context, in smithymw.BuildInput, next smithymw.BuildHandler) (smithymw.BuildOutput, smithymw.Metadata, error) {
+	if req, ok := in.Request.(*smithyhttp.Request); ok {
+		if current := req.Header.Get("User-Agent"); current != "" {
+			req.Header.Set("User-Agent", current+" "+string(a))
+		} else {
+			req.Header.Set("User-Agent", string(a))
+		}
+	}
+
+	return next.HandleBuild(ctx, in)
+}
+
+func withUserAgentAppended(value string) func(*smithymw.Stack) error {
+	return func(stack *smithymw.Stack) error {
+		// Add the appender at the end of the build step, after the SDK has set its user agent.
+		return stack.Build.Add(userAgentAppender(value), smithymw.After)
+	}
+}
+
+// expandProvisioningLock builds the lock configured in the provisioning_lock block with the given number of slots.
+func expandProvisioningLock(client *providerClient, l []interface{}, slots int) (provisioningLock, error) {
+	if len(l) == 0 || l[0] == nil {
+		return newMemoryLock(slots), nil
+	}
+	m := l[0].(map[string]interface{})
+
+	// the durations have already been validated by the schema
+	ttl, _ := time.ParseDuration(m["ttl"].(string))
+	var waitTimeout time.Duration
+	if v := m["wait_timeout"].(string); v != "" {
+		waitTimeout, _ = time.ParseDuration(v)
+	}
+
+	switch m["type"].(string) {
+	case "file":
+		path := m["file_path"].(string)
+		if path == "" {
+			return nil, fmt.Errorf("file_path must be set for the file provisioning lock")
+		}
+		return &fileLock{
+			path:        path,
+			slots:       slots,
+			ttl:         ttl,
+			waitTimeout: waitTimeout,
+		}, nil
+	case "dynamodb":
+		table := m["dynamodb_table"].(string)
+		if table == "" {
+			return nil, fmt.Errorf("dynamodb_table must be set for the dynamodb provisioning lock")
+		}
+		return &dynamodbLock{
+			client:      client.dynamodbClient(),
+			table:       table,
+			id:          m["lock_id"].(string),
+			slots:       slots,
+			ttl:         ttl,
+			waitTimeout: waitTimeout,
+		}, nil
+	default:
+		return newMemoryLock(slots), nil
+	}
 }
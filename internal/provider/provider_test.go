@@ -1,6 +1,10 @@
 package provider
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -21,6 +25,71 @@ func TestProvider(t *testing.T) {
 	}
 }
 
+func TestConfigureProviderLockUsesAssumedRole(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASSUMEDACCESSKEY</AccessKeyId>
+      <SecretAccessKey>assumed-secret</SecretAccessKey>
+      <SessionToken>assumed-token</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::123456789012:assumed-role/management/terraform</Arn>
+      <AssumedRoleId>AROAEXAMPLE:terraform</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`))
+	}))
+	defer sts.Close()
+
+	d := schema.TestResourceDataRaw(t, New("dev")().Schema, map[string]interface{}{
+		"region":                        "us-east-1",
+		"access_key":                    "BASEACCESSKEY",
+		"secret_key":                    "base-secret",
+		"skip_management_account_check": true,
+		"assume_role": []interface{}{
+			map[string]interface{}{
+				"role_arn": "arn:aws:iam::123456789012:role/management",
+			},
+		},
+		"endpoints": []interface{}{
+			map[string]interface{}{
+				"sts": sts.URL,
+			},
+		},
+		"provisioning_lock": []interface{}{
+			map[string]interface{}{
+				"type":           "dynamodb",
+				"dynamodb_table": "terraform-locks",
+			},
+		},
+	})
+
+	meta, diags := configureProvider(context.Background(), d)
+	if diags.HasError() {
+		t.Fatalf("unexpected error configuring provider: %v", diags)
+	}
+
+	lock, ok := meta.(*providerClient).provisioningLock.(*dynamodbLock)
+	if !ok {
+		t.Fatalf("expected a dynamodb lock, got %T", meta.(*providerClient).provisioningLock)
+	}
+
+	creds, err := lock.client.Options().Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error retrieving lock credentials: %v", err)
+	}
+	if creds.AccessKeyID != "ASSUMEDACCESSKEY" {
+		t.Fatalf("expected the lock to use the assumed role credentials, got access key %s", creds.AccessKeyID)
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
 	// You can add code here to run prior to any test case execution, for example assertions
 	// about the appropriate environment variables being set are common to see in a pre-check
@@ -0,0 +1,486 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/identitystore"
+	"github.com/aws/aws-sdk-go-v2/service/identitystore/document"
+	isTypes "github.com/aws/aws-sdk-go-v2/service/identitystore/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
+	ssoTypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceAccountAssignment() *schema.Resource {
+	return &schema.Resource{
+		Description: "Assigns a permission set of IAM Identity Center to a user or group on an AWS account.",
+
+		CreateContext: resourceAccountAssignmentCreate,
+		ReadContext:   resourceAccountAssignmentRead,
+		DeleteContext: resourceAccountAssignmentDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceAccountAssignmentImportState,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"account_id": {
+				Description:  "ID of the AWS account.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateAccountID,
+			},
+			"permission_set_name": {
+				Description: "Name of the permission set to assign.",
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+			},
+			"principal_type": {
+				Description:  "Type of the principal. Valid values are `USER` and `GROUP`.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{string(ssoTypes.PrincipalTypeUser), string(ssoTypes.PrincipalTypeGroup)}, false),
+			},
+			"principal_name": {
+				Description: "User name or email address of a user, or display name of a group.",
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+			},
+			"sso_instance_arn": {
+				Description:  "ARN of the IAM Identity Center instance. Overrides the provider setting of the same name.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validateARN,
+			},
+			"identity_store_id": {
+				Description:  "ID of the Identity Store of the IAM Identity Center instance. Overrides the provider setting of the same name.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(regexp.MustCompile(`^d-[0-9a-f]{10}$|^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`), "must be an Identity Store ID"),
+			},
+			"principal_id": {
+				Description: "ID of the principal in the Identity Store.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"permission_set_arn": {
+				Description: "ARN of the permission set.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+			Read:   schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+func resourceAccountAssignmentCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutCreate)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	assignment, instanceArn, err := expandAccountAssignment(ctx, client, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// The ID is set as soon as the assignment was requested, so it is kept in the state even if waiting for it fails.
+	err = createAccountAssignment(ctx, client, instanceArn, assignment, func() {
+		d.SetId(strings.Join([]string{
+			d.Get("account_id").(string),
+			d.Get("permission_set_name").(string),
+			d.Get("principal_type").(string),
+			d.Get("principal_name").(string),
+		}, ","))
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceAccountAssignmentRead(ctx, d, m)
+}
+
+func resourceAccountAssignmentRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutRead)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	assignment, instanceArn, err := stateAccountAssignment(ctx, client, d)
+	if isAccountAssignmentNotFound(err) && !d.IsNewResource() {
+		log.Printf("[WARN] Principal or permission set of account assignment %s not found, removing from state: %v", d.Id(), err)
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	exists, err := accountAssignmentExists(ctx, client.ssoadminconn, instanceArn, assignment)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if !exists && !d.IsNewResource() {
+		d.SetId("")
+		return nil
+	}
+
+	if err := d.Set("principal_id", assignment.principalId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("permission_set_arn", assignment.permissionSetArn); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceAccountAssignmentDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutDelete)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	assignment, instanceArn, err := stateAccountAssignment(ctx, client, d)
+	if isAccountAssignmentNotFound(err) {
+		// Without the principal or the permission set the assignment is gone as well.
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := deleteAccountAssignment(ctx, client, instanceArn, assignment); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceAccountAssignmentImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	accountId, permissionSetName, principalType, principalName, ok := parseAccountAssignmentId(d.Id())
+	if !ok {
+		return nil, fmt.Errorf("unexpected format of ID (%s), expected account_id,permission_set_name,principal_type,principal_name", d.Id())
+	}
+
+	if err := d.Set("account_id", accountId); err != nil {
+		return nil, err
+	}
+	if err := d.Set("permission_set_name", permissionSetName); err != nil {
+		return nil, err
+	}
+	if err := d.Set("principal_type", principalType); err != nil {
+		return nil, err
+	}
+	if err := d.Set("principal_name", principalName); err != nil {
+		return nil, err
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// parseAccountAssignmentId splits the ID of an account assignment into its parts. The principal name
+// comes last, as group display names may contain commas.
+func parseAccountAssignmentId(id string) (accountId, permissionSetName, principalType, principalName string, ok bool) {
+	parts := strings.SplitN(id, ",", 4)
+	if len(parts) != 4 {
+		return "", "", "", "", false
+	}
+
+	for _, part := range parts {
+		if part == "" {
+			return "", "", "", "", false
+		}
+	}
+
+	if parts[2] != string(ssoTypes.PrincipalTypeUser) && parts[2] != string(ssoTypes.PrincipalTypeGroup) {
+		return "", "", "", "", false
+	}
+
+	return parts[0], parts[1], parts[2], parts[3], true
+}
+
+// accountAssignment is the assignment of a permission set to a principal on an AWS account.
+type accountAssignment struct {
+	accountId        string
+	permissionSetArn string
+	principalType    ssoTypes.PrincipalType
+	principalId      string
+}
+
+func (a accountAssignment) String() string {
+	return fmt.Sprintf("%s %s on account %s", strings.ToLower(string(a.principalType)), a.principalId, a.accountId)
+}
+
+// expandAccountAssignment resolves the permission set and principal of the configured assignment.
+func expandAccountAssignment(ctx context.Context, client *providerClient, d resourceGetter) (accountAssignment, *string, error) {
+	instance, err := client.findSSOInstance(ctx, expandSSOInstance(d, client.ssoInstance))
+	if err != nil {
+		return accountAssignment{}, nil, err
+	}
+	instanceArn := instance.InstanceArn
+
+	permissionSetName := d.Get("permission_set_name").(string)
+	permissionSetArn, err := client.findPermissionSetArn(ctx, instanceArn, permissionSetName)
+	if err != nil {
+		return accountAssignment{}, nil, fmt.Errorf("error finding permission set %s: %w", permissionSetName, err)
+	}
+
+	principalType := ssoTypes.PrincipalType(d.Get("principal_type").(string))
+	principalId, err := findPrincipalId(ctx, client.identitystoreconn, instance.IdentityStoreId, principalType, d.Get("principal_name").(string))
+	if err != nil {
+		return accountAssignment{}, nil, err
+	}
+
+	return accountAssignment{
+		accountId:        d.Get("account_id").(string),
+		permissionSetArn: permissionSetArn,
+		principalType:    principalType,
+		principalId:      aws.ToString(principalId),
+	}, instanceArn, nil
+}
+
+// stateAccountAssignment returns the assignment with the principal and permission set stored in the state,
+// so it is found even if they were renamed or deleted. Imported assignments are resolved by name.
+func stateAccountAssignment(ctx context.Context, client *providerClient, d *schema.ResourceData) (accountAssignment, *string, error) {
+	principalId := d.Get("principal_id").(string)
+	permissionSetArn := d.Get("permission_set_arn").(string)
+	if principalId == "" || permissionSetArn == "" {
+		return expandAccountAssignment(ctx, client, d)
+	}
+
+	instance, err := client.findSSOInstance(ctx, expandSSOInstance(d, client.ssoInstance))
+	if err != nil {
+		return accountAssignment{}, nil, err
+	}
+
+	return accountAssignment{
+		accountId:        d.Get("account_id").(string),
+		permissionSetArn: permissionSetArn,
+		principalType:    ssoTypes.PrincipalType(d.Get("principal_type").(string)),
+		principalId:      principalId,
+	}, instance.InstanceArn, nil
+}
+
+// isAccountAssignmentNotFound reports whether the principal or the permission set of an assignment does not exist.
+func isAccountAssignmentNotFound(err error) bool {
+	var identitystoreNotFoundErr *isTypes.ResourceNotFoundException
+	var ssoadminNotFoundErr *ssoTypes.ResourceNotFoundException
+
+	return errors.Is(err, errPermissionSetNotFound) || errors.As(err, &identitystoreNotFoundErr) || errors.As(err, &ssoadminNotFoundErr)
+}
+
+// findPrincipalId returns the ID of a user by user name or email address, or of a group by display name.
+func findPrincipalId(ctx context.Context, identitystoreconn *identitystore.Client, identityStoreId *string, principalType ssoTypes.PrincipalType, name string) (*string, error) {
+	if principalType == ssoTypes.PrincipalTypeGroup {
+		group, err := identitystoreconn.GetGroupId(ctx, &identitystore.GetGroupIdInput{
+			IdentityStoreId: identityStoreId,
+			AlternateIdentifier: &isTypes.AlternateIdentifierMemberUniqueAttribute{
+				Value: isTypes.UniqueAttribute{
+					AttributePath:  aws.String("DisplayName"),
+					AttributeValue: document.NewLazyDocument(name),
+				},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error getting group id of %s: %w", name, err)
+		}
+		return group.GroupId, nil
+	}
+
+	userId, err := findPrincipalUserId(ctx, identityStoreId, name, identitystoreconn)
+	var notFoundErr *isTypes.ResourceNotFoundException
+	if !errors.As(err, &notFoundErr) {
+		return userId, err
+	}
+
+	// The user name differs from the email address.
+	user, err := identitystoreconn.GetUserId(ctx, &identitystore.GetUserIdInput{
+		IdentityStoreId: identityStoreId,
+		AlternateIdentifier: &isTypes.AlternateIdentifierMemberUniqueAttribute{
+			Value: isTypes.UniqueAttribute{
+				AttributePath:  aws.String("Emails.Value"),
+				AttributeValue: document.NewLazyDocument(name),
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting user id of %s: %w", name, err)
+	}
+	return user.UserId, nil
+}
+
+// accountAssignmentExists checks whether the assignment exists on the account.
+func accountAssignmentExists(ctx context.Context, ssoadminconn *ssoadmin.Client, instanceArn *string, assignment accountAssignment) (bool, error) {
+	paginator := ssoadmin.NewListAccountAssignmentsPaginator(ssoadminconn, &ssoadmin.ListAccountAssignmentsInput{
+		AccountId:        aws.String(assignment.accountId),
+		InstanceArn:      instanceArn,
+		PermissionSetArn: aws.String(assignment.permissionSetArn),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		var notFoundErr *ssoTypes.ResourceNotFoundException
+		if errors.As(err, &notFoundErr) {
+			// The permission set was deleted together with its assignments.
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("error listing account assignments of account %s: %w", assignment.accountId, err)
+		}
+
+		for _, a := range output.AccountAssignments {
+			if a.PrincipalType == assignment.principalType && aws.ToString(a.PrincipalId) == assignment.principalId {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// ensureAccountAssignment creates the assignment unless it exists already.
+func ensureAccountAssignment(ctx context.Context, client *providerClient, instanceArn *string, assignment accountAssignment) error {
+	exists, err := accountAssignmentExists(ctx, client.ssoadminconn, instanceArn, assignment)
+	if err != nil || exists {
+		return err
+	}
+
+	return createAccountAssignment(ctx, client, instanceArn, assignment, nil)
+}
+
+// createAccountAssignment assigns the permission set and waits until the assignment succeeded.
+// If set, requested is called once the assignment was requested, before it is waited for.
+func createAccountAssignment(ctx context.Context, client *providerClient, instanceArn *string, assignment accountAssignment, requested func()) error {
+	ssoadminconn := client.ssoadminconn
+
+	var output *ssoadmin.CreateAccountAssignmentOutput
+	err := retryOnConflict(ctx, assignment.String(), func() (err error) {
+		output, err = ssoadminconn.CreateAccountAssignment(ctx, &ssoadmin.CreateAccountAssignmentInput{
+			InstanceArn:      instanceArn,
+			PermissionSetArn: aws.String(assignment.permissionSetArn),
+			PrincipalId:      aws.String(assignment.principalId),
+			PrincipalType:    assignment.principalType,
+			TargetId:         aws.String(assignment.accountId),
+			TargetType:       ssoTypes.TargetTypeAwsAccount,
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error assigning %s: %v", assignment, err)
+	}
+	if requested != nil {
+		requested()
+	}
+
+	return waitForAccountAssignment(ctx, "assignment of "+assignment.String(), output.AccountAssignmentCreationStatus, func(requestId *string) (*ssoTypes.AccountAssignmentOperationStatus, error) {
+		status, err := ssoadminconn.DescribeAccountAssignmentCreationStatus(ctx, &ssoadmin.DescribeAccountAssignmentCreationStatusInput{
+			InstanceArn:                        instanceArn,
+			AccountAssignmentCreationRequestId: requestId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return status.AccountAssignmentCreationStatus, nil
+	})
+}
+
+// deleteAccountAssignment removes the permission set and waits until the removal succeeded.
+// Assignments that do not exist (anymore) are ignored.
+func deleteAccountAssignment(ctx context.Context, client *providerClient, instanceArn *string, assignment accountAssignment) error {
+	ssoadminconn := client.ssoadminconn
+
+	var output *ssoadmin.DeleteAccountAssignmentOutput
+	err := retryOnConflict(ctx, assignment.String(), func() (err error) {
+		output, err = ssoadminconn.DeleteAccountAssignment(ctx, &ssoadmin.DeleteAccountAssignmentInput{
+			InstanceArn:      instanceArn,
+			PermissionSetArn: aws.String(assignment.permissionSetArn),
+			PrincipalId:      aws.String(assignment.principalId),
+			PrincipalType:    assignment.principalType,
+			TargetId:         aws.String(assignment.accountId),
+			TargetType:       ssoTypes.TargetTypeAwsAccount,
+		})
+		return err
+	})
+	var notFoundErr *ssoTypes.ResourceNotFoundException
+	if errors.As(err, &notFoundErr) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error unassigning %s: %v", assignment, err)
+	}
+
+	return waitForAccountAssignment(ctx, "unassignment of "+assignment.String(), output.AccountAssignmentDeletionStatus, func(requestId *string) (*ssoTypes.AccountAssignmentOperationStatus, error) {
+		status, err := ssoadminconn.DescribeAccountAssignmentDeletionStatus(ctx, &ssoadmin.DescribeAccountAssignmentDeletionStatusInput{
+			InstanceArn:                        instanceArn,
+			AccountAssignmentDeletionRequestId: requestId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return status.AccountAssignmentDeletionStatus, nil
+	})
+}
+
+// accountAssignmentPollingConfig polls account assignments, which usually finish within seconds, every
+// second at first.
+var accountAssignmentPollingConfig = pollingConfig{
+	interval:                time.Second,
+	maxInterval:             5 * time.Second,
+	backoffMultiplier:       2,
+	minConsecutiveSuccesses: 1,
+}
+
+// waitForAccountAssignment waits until the creation or deletion of an account assignment succeeded.
+func waitForAccountAssignment(ctx context.Context, description string, status *ssoTypes.AccountAssignmentOperationStatus, describe func(requestId *string) (*ssoTypes.AccountAssignmentOperationStatus, error)) error {
+	if status == nil {
+		return nil
+	}
+	requestId := status.RequestId
+
+	return waitForOperation(ctx, description, accountAssignmentPollingConfig, func() (bool, error) {
+		// The initial status is checked first, it is often final already.
+		if status == nil {
+			var err error
+			status, err = describe(requestId)
+			if err != nil {
+				return false, fmt.Errorf("error describing %s: %v", description, err)
+			}
+		}
+
+		current := status
+		status = nil
+
+		switch current.Status {
+		case ssoTypes.StatusValuesSucceeded:
+			return true, nil
+		case ssoTypes.StatusValuesFailed:
+			return false, fmt.Errorf("%s failed: %s", description, aws.ToString(current.FailureReason))
+		default:
+			return false, nil
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	isTypes "github.com/aws/aws-sdk-go-v2/service/identitystore/types"
+	ssoTypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
+)
+
+func TestParseAccountAssignmentId(t *testing.T) {
+	cases := []struct {
+		id                string
+		accountId         string
+		permissionSetName string
+		principalType     string
+		principalName     string
+		ok                bool
+	}{
+		{
+			id:                "123456789012,AWSReadOnlyAccess,GROUP,Platform, Admins",
+			accountId:         "123456789012",
+			permissionSetName: "AWSReadOnlyAccess",
+			principalType:     "GROUP",
+			principalName:     "Platform, Admins",
+			ok:                true,
+		},
+		{
+			id:                "123456789012,AWSPowerUserAccess,USER,jane.doe@example.com",
+			accountId:         "123456789012",
+			permissionSetName: "AWSPowerUserAccess",
+			principalType:     "USER",
+			principalName:     "jane.doe@example.com",
+			ok:                true,
+		},
+		{id: "123456789012,AWSPowerUserAccess,ROLE,jane.doe@example.com"},
+		{id: "123456789012,AWSPowerUserAccess,USER,"},
+		{id: "123456789012,AWSPowerUserAccess"},
+	}
+
+	for _, c := range cases {
+		accountId, permissionSetName, principalType, principalName, ok := parseAccountAssignmentId(c.id)
+		if accountId != c.accountId || permissionSetName != c.permissionSetName || principalType != c.principalType || principalName != c.principalName || ok != c.ok {
+			t.Errorf("%s: expected (%q, %q, %q, %q, %t), got (%q, %q, %q, %q, %t)", c.id, c.accountId, c.permissionSetName, c.principalType, c.principalName, c.ok, accountId, permissionSetName, principalType, principalName, ok)
+		}
+	}
+}
+
+func TestIsAccountAssignmentNotFound(t *testing.T) {
+	cases := []struct {
+		err      error
+		notFound bool
+	}{
+		{err: fmt.Errorf("error finding permission set AWSReadOnlyAccess: %w", errPermissionSetNotFound), notFound: true},
+		{err: fmt.Errorf("error getting group id of Platform Admins: %w", &isTypes.ResourceNotFoundException{}), notFound: true},
+		{err: fmt.Errorf("error listing account assignments: %w", &ssoTypes.ResourceNotFoundException{}), notFound: true},
+		{err: fmt.Errorf("error listing account assignments: %w", &ssoTypes.AccessDeniedException{})},
+		{err: nil},
+	}
+
+	for _, c := range cases {
+		if notFound := isAccountAssignmentNotFound(c.err); notFound != c.notFound {
+			t.Errorf("%v: expected %t, got %t", c.err, c.notFound, notFound)
+		}
+	}
+}
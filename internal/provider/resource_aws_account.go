@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/identitystore"
@@ -13,15 +14,16 @@ import (
 	orgTypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
 	scTypes "github.com/aws/aws-sdk-go-v2/service/servicecatalog/types"
 	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
+	ssoTypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
 
 	"regexp"
-	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/organizations"
 	"github.com/aws/aws-sdk-go-v2/service/servicecatalog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
@@ -41,6 +43,11 @@ func resourceAWSAccount() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceAWSAccountImportState,
 		},
+		CustomizeDiff: customdiff.All(
+			setTagsDiff,
+			provisioningArtifactDiff,
+			ssoUserDiff,
+		),
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -77,19 +84,37 @@ func resourceAWSAccount() *schema.Resource {
 						},
 
 						"email": {
-							Description:  "Email address of the user. If you use automatic provisioning this email address should already exist in AWS SSO.",
+							Description:  "Email address of the user. If you use automatic provisioning this email address should already exist in AWS SSO, unless `create_user_if_missing` is enabled. Otherwise a missing user is left to the Account Factory, which creates it in the built-in directory. A user that is missing during the plan is only reported in the provider log as a warning.",
 							Type:         schema.TypeString,
 							Required:     true,
 							ValidateFunc: validateEmailAddress,
 						},
+						"create_user_if_missing": {
+							Description: "If enabled, the user is created in the Identity Store before the account is provisioned, in case it does not exist yet.",
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+						},
 						"remove_account_assignment_on_update": {
-							Description: "If enabled, this will remove the account assignment for the old SSO user when the resource is updated.",
+							Description: "If enabled, this will remove the account assignment for the old SSO user or group when the resource is updated.",
 							Type:        schema.TypeBool,
 							Optional:    true,
 							Default:     false,
 						},
+						"group_name": {
+							Description:   "Display name of an Identity Store group, which is assigned the permission set instead of the user. Conflicts with `group_id`.",
+							Type:          schema.TypeString,
+							Optional:      true,
+							ConflictsWith: []string{"sso.0.group_id"},
+						},
+						"group_id": {
+							Description:   "ID of an Identity Store group, which is assigned the permission set instead of the user. Conflicts with `group_name`.",
+							Type:          schema.TypeString,
+							Optional:      true,
+							ConflictsWith: []string{"sso.0.group_name"},
+						},
 						"permission_set_name": {
-							Description: "Permission set name for the sso user. Defaults to AWSAdministratorAccess.",
+							Description: "Permission set name for the sso user or group. The assignment is created if it is missing and moved when the permission set changes. Defaults to AWSAdministratorAccess.",
 							Type:        schema.TypeString,
 							Required:    false,
 							Optional:    true,
@@ -98,6 +123,11 @@ func resourceAWSAccount() *schema.Resource {
 					},
 				},
 			},
+			"sso_user_id": {
+				Description: "ID of the SSO user in the Identity Store, which is looked up on every read. Empty if the user does not exist (yet).",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
 			"organizational_unit": {
 				Description: "Name of the Organizational Unit under which the account resides.",
 				Type:        schema.TypeString,
@@ -109,8 +139,14 @@ func resourceAWSAccount() *schema.Resource {
 				Optional:    true,
 				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
+			"tags_all": {
+				Description: "Map of tags assigned to the account, including those inherited from the provider `default_tags` configuration block.",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
 			"path_id": {
-				Description:  "Name of the path identifier of the product. This value is optional if the product has a default path, and required if the product has more than one path. To list the paths for a product, use ListLaunchPaths.",
+				Description:  "Name of the path identifier of the product. This value is optional if the product has a default path, and required if the product has more than one path. To list the paths for a product, use the `launch_paths` of the `controltower_account_factory_product` data source.",
 				Type:         schema.TypeString,
 				Optional:     true,
 				Computed:     true,
@@ -137,6 +173,58 @@ func resourceAWSAccount() *schema.Resource {
 				Optional:    true,
 				Default:     false,
 			},
+			"account_factory_product_id": {
+				Description:   "ID of the Account Factory product in Service Catalog. Overrides the provider setting of the same name. Conflicts with `account_factory_product_name`.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"account_factory_product_name"},
+				ValidateFunc:  validation.StringMatch(regexp.MustCompile(`^prod-[a-z0-9]+$`), "must be a Service Catalog product ID"),
+			},
+			"account_factory_product_name": {
+				Description:   "Name of the Account Factory product in Service Catalog. Overrides the provider setting of the same name. Conflicts with `account_factory_product_id`.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"account_factory_product_id"},
+			},
+			"account_factory_product_name_match": {
+				Description:  "How the Account Factory product name is matched. Valid values are `full_text` and `exact`. Overrides the provider setting of the same name.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"full_text", "exact"}, false),
+			},
+			"provisioning_artifact_id": {
+				Description:   "ID of the provisioning artifact (version) of the Account Factory product. If set, the account is pinned to this artifact, otherwise it shows the artifact in use. New accounts use the active artifact by default. Conflicts with `provisioning_artifact_name`.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"provisioning_artifact_name"},
+				ValidateFunc:  validation.StringMatch(regexp.MustCompile(`^pa-[a-z0-9]+$`), "must be a Service Catalog provisioning artifact ID"),
+			},
+			"provisioning_artifact_name": {
+				Description:   "Name of the provisioning artifact (version) of the Account Factory product to pin the account to. Conflicts with `provisioning_artifact_id`.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"provisioning_artifact_id"},
+			},
+			"upgrade_provisioning_artifact": {
+				Description: "If enabled and no artifact is pinned, the plan shows an upgrade of `provisioning_artifact_id` whenever the active artifact of the Account Factory product changes. Otherwise updates keep the artifact in use.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"sso_instance_arn": {
+				Description:  "ARN of the IAM Identity Center instance used for the account assignment. Overrides the provider setting of the same name.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateARN,
+			},
+			"identity_store_id": {
+				Description:  "ID of the Identity Store of the IAM Identity Center instance used for the account assignment. Overrides the provider setting of the same name.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringMatch(regexp.MustCompile(`^d-[0-9a-f]{10}$|^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`), "must be an Identity Store ID"),
+			},
+			"provisioning_polling": pollingSchema("Configuration block for polling the Account Factory operations of this account.", false),
 			"account_id": {
 				Description: "ID of the AWS account.",
 				Type:        schema.TypeString,
@@ -152,24 +240,32 @@ func resourceAWSAccount() *schema.Resource {
 	}
 }
 
-var accountMutex sync.Mutex
-
 func resourceAWSAccountCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	// Create context with configured timeout
 	timeout := d.Timeout(schema.TimeoutCreate)
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	cfg := m.(aws.Config)
+	client := m.(*providerClient)
 
-	scconn := servicecatalog.NewFromConfig(cfg)
-	organizationsconn := organizations.NewFromConfig(cfg)
+	scconn := client.scconn
+	organizationsconn := client.organizationsconn
 
-	productId, artifactId, err := findServiceCatalogAccountProductId(ctx, scconn)
+	productId, artifactId, err := client.findAccountFactoryProduct(ctx, expandAccountFactoryProduct(d, client.accountFactoryProduct))
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	// Use the pinned artifact instead of the active one.
+	if v, ok := d.GetOk("provisioning_artifact_id"); ok {
+		artifactId = aws.String(v.(string))
+	} else if v, ok := d.GetOk("provisioning_artifact_name"); ok {
+		artifactId, err = findProvisioningArtifactIdByName(ctx, scconn, productId, v.(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	// Get the name, ou and SSO details from the config.
 	name := d.Get("name").(string)
 	ou := d.Get("organizational_unit").(string)
@@ -181,9 +277,17 @@ func resourceAWSAccountCreate(ctx context.Context, d *schema.ResourceData, m int
 		ppn = invalidProductNameChars.ReplaceAllString(name, "_")
 	}
 
+	// The Account Factory fails late if the SSO user cannot be assigned.
+	if sso["create_user_if_missing"].(bool) {
+		if err := ensureSSOUser(ctx, client, expandSSOInstance(d, client.ssoInstance), sso); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	// Create a new parameters struct.
 	params := &servicecatalog.ProvisionProductInput{
 		ProductId:              productId,
+		ProvisionToken:         aws.String(idempotencyToken()),
 		ProvisionedProductName: aws.String(ppn),
 		ProvisioningArtifactId: artifactId,
 		ProvisioningParameters: []scTypes.ProvisioningParameter{
@@ -219,10 +323,17 @@ func resourceAWSAccountCreate(ctx context.Context, d *schema.ResourceData, m int
 		params.PathId = aws.String(v.(string))
 	}
 
-	accountMutex.Lock()
-	defer accountMutex.Unlock()
+	release, err := client.provisioningLock.acquire(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
 
-	account, err := scconn.ProvisionProduct(ctx, params)
+	var account *servicecatalog.ProvisionProductOutput
+	err = retryOnConflict(ctx, name, func() error {
+		account, err = scconn.ProvisionProduct(ctx, params)
+		return err
+	})
 	if err != nil {
 		return diag.Errorf("error provisioning account %s: %v", name, err)
 	}
@@ -231,15 +342,19 @@ func resourceAWSAccountCreate(ctx context.Context, d *schema.ResourceData, m int
 	d.SetId(*account.RecordDetail.ProvisionedProductId)
 
 	// Wait for the provisioning to finish.
-	record, diags := waitForProvisioning(ctx, name, account.RecordDetail.RecordId, scconn)
+	record, diags := waitForProvisioning(ctx, name, account.RecordDetail.RecordId, scconn, expandPollingConfig(d.Get("provisioning_polling").([]interface{}), rawPollingConfig(d), client.polling))
 	if diags.HasError() {
 		return diags
 	}
 
-	tags := d.Get("tags").(map[string]interface{})
+	tags := client.mergeDefaultTags(d.Get("tags").(map[string]interface{}))
 	for _, output := range record.RecordOutputs {
 		switch *output.OutputKey {
 		case "AccountId":
+			if len(tags) == 0 {
+				continue
+			}
+
 			_, err := organizationsconn.TagResource(ctx, &organizations.TagResourceInput{
 				ResourceId: output.OutputValue,
 				Tags:       toOrganizationsTags(tags),
@@ -250,6 +365,17 @@ func resourceAWSAccountCreate(ctx context.Context, d *schema.ResourceData, m int
 		}
 	}
 
+	// The Account Factory only assigns the user with its own permission set, so the configured
+	// assignment is created afterwards if it is missing.
+	accountId := parseAccountRecordOutputs(record.RecordOutputs).accountId
+	assignment, instanceArn, err := ssoAccountAssignment(ctx, client, expandSSOInstance(d, client.ssoInstance), accountId, sso)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := ensureAccountAssignment(ctx, client, instanceArn, assignment); err != nil {
+		return diag.FromErr(err)
+	}
+
 	return resourceAWSAccountRead(ctx, d, m)
 }
 
@@ -259,10 +385,10 @@ func resourceAWSAccountRead(ctx context.Context, d *schema.ResourceData, m inter
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	cfg := m.(aws.Config)
+	client := m.(*providerClient)
 
-	scconn := servicecatalog.NewFromConfig(cfg)
-	organizationsconn := organizations.NewFromConfig(cfg)
+	scconn := client.scconn
+	organizationsconn := client.organizationsconn
 
 	product, err := scconn.DescribeProvisionedProduct(ctx, &servicecatalog.DescribeProvisionedProductInput{
 		Id: aws.String(d.Id()),
@@ -291,7 +417,6 @@ func resourceAWSAccountRead(ctx context.Context, d *schema.ResourceData, m inter
 	}
 
 	// update config
-	var accountId string
 	sso := map[string]interface{}{
 		"first_name": "",
 		"last_name":  "",
@@ -307,30 +432,45 @@ func resourceAWSAccountRead(ctx context.Context, d *schema.ResourceData, m inter
 		return diag.FromErr(err)
 	}
 
+	if err := d.Set("provisioning_artifact_id", product.ProvisionedProductDetail.ProvisioningArtifactId); err != nil {
+		return diag.FromErr(err)
+	}
+
 	if err = d.Set("path_id", *status.RecordDetail.PathId); err != nil {
 		return diag.FromErr(err)
 	}
 
-	for _, output := range status.RecordOutputs {
-		switch *output.OutputKey {
-		case "AccountEmail":
-			if err := d.Set("email", *output.OutputValue); err != nil {
-				return diag.FromErr(err)
-			}
-		case "AccountId":
-			accountId = *output.OutputValue
-			if err := d.Set("account_id", *output.OutputValue); err != nil {
-				return diag.FromErr(err)
-			}
-		case "SSOUserEmail":
-			sso["email"] = *output.OutputValue
+	outputs := parseAccountRecordOutputs(status.RecordOutputs)
+	if outputs.accountEmail != "" {
+		if err := d.Set("email", outputs.accountEmail); err != nil {
+			return diag.FromErr(err)
 		}
 	}
+	if outputs.accountId != "" {
+		if err := d.Set("account_id", outputs.accountId); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if outputs.ssoUserEmail != "" {
+		sso["email"] = outputs.ssoUserEmail
+	}
 	if err := d.Set("sso", []interface{}{sso}); err != nil {
 		return diag.FromErr(err)
 	}
 
+	// The user may have been created by the Account Factory, so its ID is looked up on every read.
+	// The lookup is not needed to manage the account, so failures are only logged.
+	if email, _ := sso["email"].(string); email != "" {
+		userId, err := findSSOUserId(ctx, client, expandSSOInstance(d, client.ssoInstance), email)
+		if err != nil {
+			log.Printf("[WARN] Error looking up SSO user %s of provisioned product %s: %v", email, d.Id(), err)
+		} else if err := d.Set("sso_user_id", userId); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	// exit read if no account id is found in the product
+	accountId := outputs.accountId
 	if accountId == "" {
 		return nil
 	}
@@ -345,7 +485,7 @@ func resourceAWSAccountRead(ctx context.Context, d *schema.ResourceData, m inter
 		return diag.FromErr(err)
 	}
 
-	ou, err := findParentOrganizationalUnit(ctx, organizationsconn, accountId)
+	ou, err := findParentOrganizationalUnit(ctx, client, accountId)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -359,31 +499,60 @@ func resourceAWSAccountRead(ctx context.Context, d *schema.ResourceData, m inter
 	if err != nil {
 		return diag.Errorf("error listing tags for resource %s: %v", accountId, err)
 	}
-	if err := d.Set("tags", fromOrganizationTags(tags.Tags)); err != nil {
+	allTags := fromOrganizationTags(tags.Tags, client.ignoreTags)
+	if err := d.Set("tags", client.removeDefaultTags(allTags, d.Get("tags").(map[string]interface{}))); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("tags_all", allTags); err != nil {
 		return diag.FromErr(err)
 	}
 
 	return nil
 }
 
+// accountFactoryAttributes are the attributes sent to the Account Factory. Only changes to them require an
+// update of the provisioned product, all other attributes are handled by the provider itself.
+var accountFactoryAttributes = []string{
+	"name",
+	"email",
+	"organizational_unit",
+	"sso.0.first_name",
+	"sso.0.last_name",
+	"sso.0.email",
+	"account_factory_product_id",
+	"provisioning_artifact_id",
+	"provisioning_artifact_name",
+}
+
 func resourceAWSAccountUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	// Create context with configured timeout
 	timeout := d.Timeout(schema.TimeoutUpdate)
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	cfg := m.(aws.Config)
+	client := m.(*providerClient)
 
-	scconn := servicecatalog.NewFromConfig(cfg)
-	organizationsconn := organizations.NewFromConfig(cfg)
+	scconn := client.scconn
+	organizationsconn := client.organizationsconn
 	sso := d.Get("sso").([]interface{})[0].(map[string]interface{})
 
-	if d.HasChangesExcept("tags", "organizational_unit_id_on_delete", "close_account_on_delete") {
-		productId, artifactId, err := findServiceCatalogAccountProductId(ctx, scconn)
+	if d.HasChange("sso") && sso["create_user_if_missing"].(bool) {
+		if err := ensureSSOUser(ctx, client, expandSSOInstance(d, client.ssoInstance), sso); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChanges(accountFactoryAttributes...) {
+		productId, artifactId, err := client.findAccountFactoryProduct(ctx, expandAccountFactoryProduct(d, client.accountFactoryProduct))
 		if err != nil {
 			return diag.FromErr(err)
 		}
 
+		// Keep the artifact in use, unless it is pinned or an upgrade was planned.
+		if v, ok := d.GetOk("provisioning_artifact_id"); ok {
+			artifactId = aws.String(v.(string))
+		}
+
 		// Get the name, email, ou and SSO details from the config.
 		name := d.Get("name").(string)
 		email := d.Get("email").(string)
@@ -392,6 +561,7 @@ func resourceAWSAccountUpdate(ctx context.Context, d *schema.ResourceData, m int
 		// Create a new parameters struct.
 		params := &servicecatalog.UpdateProvisionedProductInput{
 			ProvisionedProductId:   aws.String(d.Id()),
+			UpdateToken:            aws.String(idempotencyToken()),
 			ProductId:              productId,
 			ProvisioningArtifactId: artifactId,
 			ProvisioningParameters: []scTypes.UpdateProvisioningParameter{
@@ -427,41 +597,44 @@ func resourceAWSAccountUpdate(ctx context.Context, d *schema.ResourceData, m int
 			params.PathId = aws.String(pathIdConfig.AsString())
 		}
 
-		accountMutex.Lock()
-		defer accountMutex.Unlock()
+		release, err := client.provisioningLock.acquire(ctx)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		defer release()
 
-		account, err := scconn.UpdateProvisionedProduct(ctx, params)
+		var account *servicecatalog.UpdateProvisionedProductOutput
+		err = retryOnConflict(ctx, name, func() error {
+			account, err = scconn.UpdateProvisionedProduct(ctx, params)
+			return err
+		})
 		if err != nil {
 			return diag.Errorf("error updating provisioned account %s: %v", name, err)
 		}
 
 		// Wait for the provisioning to finish.
-		_, diags := waitForProvisioning(ctx, name, account.RecordDetail.RecordId, scconn)
+		_, diags := waitForProvisioning(ctx, name, account.RecordDetail.RecordId, scconn, expandPollingConfig(d.Get("provisioning_polling").([]interface{}), rawPollingConfig(d), client.polling))
 		if diags.HasError() {
 			return diags
 		}
 	}
 
-	if d.HasChange("tags") {
-		o, n := d.GetChange("tags")
+	if d.HasChange("tags_all") {
+		o, n := d.GetChange("tags_all")
 		accountId := d.Get("account_id").(string)
 
-		if err := updateAccountTags(ctx, organizationsconn, accountId, o, n); err != nil {
+		if err := updateAccountTags(ctx, organizationsconn, accountId, o, n, client.ignoreTags); err != nil {
 			return diag.Errorf("error updating AWS Organizations Account (%s) tags: %s", accountId, err)
 		}
 	}
 
-	isRemoveAccountAssignmentOnUpdate := sso["remove_account_assignment_on_update"].(bool)
-
-	if isRemoveAccountAssignmentOnUpdate && d.HasChange("sso") {
-		ssoadminconn := ssoadmin.NewFromConfig(cfg)
-		identitystoreconn := identitystore.NewFromConfig(cfg)
-
+	// Only the principal and the permission set affect the assignment.
+	if d.HasChanges("sso.0.email", "sso.0.group_name", "sso.0.group_id", "sso.0.permission_set_name") {
 		accountId := d.Get("account_id").(string)
-		permissionSetName := sso["permission_set_name"].(string)
+		isRemoveAccountAssignmentOnUpdate := sso["remove_account_assignment_on_update"].(bool)
 
 		o, n := d.GetChange("sso")
-		if err := updateAccountAssignment(ctx, ssoadminconn, identitystoreconn, accountId, permissionSetName, o, n); err != nil {
+		if err := updateAccountAssignment(ctx, client, expandSSOInstance(d, client.ssoInstance), accountId, o, n, isRemoveAccountAssignmentOnUpdate); err != nil {
 			return diag.Errorf("error updating account assignment: %v", err)
 		}
 	}
@@ -469,48 +642,185 @@ func resourceAWSAccountUpdate(ctx context.Context, d *schema.ResourceData, m int
 	return resourceAWSAccountRead(ctx, d, m)
 }
 
-func updateAccountAssignment(ctx context.Context, ssoadminconn *ssoadmin.Client, identitystoreconn *identitystore.Client, accountId string, permissionSetName string, oldSSO interface{}, newSSO interface{}) error {
-
+// updateAccountAssignment creates the configured assignment if it is missing and removes the old one.
+// The assignment is moved if only the permission set changed, the assignment of an old user or group
+// is only removed if removeOld is set.
+func updateAccountAssignment(ctx context.Context, client *providerClient, selection ssoInstance, accountId string, oldSSO interface{}, newSSO interface{}, removeOld bool) error {
 	oldSSOMap := oldSSO.([]interface{})[0].(map[string]interface{})
 	newSSOMap := newSSO.([]interface{})[0].(map[string]interface{})
-	oldEmail := oldSSOMap["email"].(string)
-	newEmail := newSSOMap["email"].(string)
 
-	ssoInstances, err := ssoadminconn.ListInstances(ctx, &ssoadmin.ListInstancesInput{})
+	newAssignment, instanceArn, err := ssoAccountAssignment(ctx, client, selection, accountId, newSSOMap)
 	if err != nil {
-		return fmt.Errorf("error listing SSO instances: %v", err)
+		return err
+	}
+
+	// The Account Factory only assigns the user with its own permission set.
+	if err := ensureAccountAssignment(ctx, client, instanceArn, newAssignment); err != nil {
+		return err
+	}
+
+	oldAssignment, _, err := ssoAccountAssignment(ctx, client, selection, accountId, oldSSOMap)
+	if isAccountAssignmentNotFound(err) {
+		// The old principal or permission set was deleted together with its assignments.
+		return nil
 	}
-	instanceArn := ssoInstances.Instances[0].InstanceArn
-	principalUserId, err := findPrincipalUserId(ctx, ssoInstances, oldEmail, identitystoreconn)
 	if err != nil {
 		return err
 	}
 
-	permissionSetArn, err := findPermissionSetArn(ctx, ssoadminconn, instanceArn, permissionSetName)
+	if oldAssignment == newAssignment {
+		return nil
+	}
+
+	samePrincipal := oldAssignment.principalType == newAssignment.principalType && oldAssignment.principalId == newAssignment.principalId
+	if !samePrincipal && !removeOld {
+		return nil
+	}
+
+	return deleteAccountAssignment(ctx, client, instanceArn, oldAssignment)
+}
 
+// ssoAccountAssignment returns the assignment of the permission set to the principal of the sso block,
+// which is the group if one is configured and the user otherwise.
+func ssoAccountAssignment(ctx context.Context, client *providerClient, selection ssoInstance, accountId string, sso map[string]interface{}) (accountAssignment, *string, error) {
+	instance, err := client.findSSOInstance(ctx, selection)
 	if err != nil {
-		return fmt.Errorf("error finding permission set: %v", err)
+		return accountAssignment{}, nil, err
 	}
-	if oldEmail != newEmail {
+	instanceArn := instance.InstanceArn
 
-		_, err := ssoadminconn.DeleteAccountAssignment(ctx, &ssoadmin.DeleteAccountAssignmentInput{
-			InstanceArn:      instanceArn,
-			TargetId:         &accountId,
-			TargetType:       "AWS_ACCOUNT",
-			PrincipalType:    "USER",
-			PrincipalId:      principalUserId,
-			PermissionSetArn: &permissionSetArn,
-		})
-		if err != nil {
-			return fmt.Errorf("error unassigning SSO user from account (%s): %v", accountId, err)
-		}
+	permissionSetArn, err := client.findPermissionSetArn(ctx, instanceArn, sso["permission_set_name"].(string))
+	if err != nil {
+		return accountAssignment{}, nil, fmt.Errorf("error finding permission set: %w", err)
 	}
+
+	assignment := accountAssignment{
+		accountId:        accountId,
+		permissionSetArn: permissionSetArn,
+	}
+
+	groupId, _ := sso["group_id"].(string)
+	groupName, _ := sso["group_name"].(string)
+
+	var principalId *string
+	switch {
+	case groupId != "":
+		assignment.principalType = ssoTypes.PrincipalTypeGroup
+		principalId = aws.String(groupId)
+	case groupName != "":
+		assignment.principalType = ssoTypes.PrincipalTypeGroup
+		principalId, err = findPrincipalId(ctx, client.identitystoreconn, instance.IdentityStoreId, ssoTypes.PrincipalTypeGroup, groupName)
+	default:
+		assignment.principalType = ssoTypes.PrincipalTypeUser
+		principalId, err = findPrincipalUserId(ctx, instance.IdentityStoreId, sso["email"].(string), client.identitystoreconn)
+	}
+	if err != nil {
+		return accountAssignment{}, nil, err
+	}
+	assignment.principalId = aws.ToString(principalId)
+
+	return assignment, instanceArn, nil
+}
+
+// findSSOUserId returns the ID of the SSO user with the given email, or an empty string if it does not exist.
+func findSSOUserId(ctx context.Context, client *providerClient, selection ssoInstance, email string) (string, error) {
+	instance, err := client.findSSOInstance(ctx, selection)
+	if err != nil {
+		return "", err
+	}
+
+	userId, err := findPrincipalUserId(ctx, instance.IdentityStoreId, email, client.identitystoreconn)
+	var notFoundErr *types.ResourceNotFoundException
+	if errors.As(err, &notFoundErr) {
+		return "", nil
+	}
+	return aws.ToString(userId), err
+}
+
+// ensureSSOUser creates the SSO user in the Identity Store, unless it exists already. It is only called if
+// create_user_if_missing is enabled, otherwise a missing user is left to the Account Factory.
+func ensureSSOUser(ctx context.Context, client *providerClient, selection ssoInstance, sso map[string]interface{}) error {
+	email := sso["email"].(string)
+	userId, err := findSSOUserId(ctx, client, selection, email)
+	if err != nil || userId != "" {
+		return err
+	}
+
+	instance, err := client.findSSOInstance(ctx, selection)
+	if err != nil {
+		return err
+	}
+
+	firstName := sso["first_name"].(string)
+	lastName := sso["last_name"].(string)
+
+	_, err = client.identitystoreconn.CreateUser(ctx, &identitystore.CreateUserInput{
+		IdentityStoreId: instance.IdentityStoreId,
+		UserName:        aws.String(email),
+		DisplayName:     aws.String(firstName + " " + lastName),
+		Name: &types.Name{
+			GivenName:  aws.String(firstName),
+			FamilyName: aws.String(lastName),
+		},
+		Emails: []types.Email{
+			{
+				Value:   aws.String(email),
+				Primary: true,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating SSO user %s: %v", email, err)
+	}
+
 	return nil
 }
 
-func findPrincipalUserId(ctx context.Context, ssoInstances *ssoadmin.ListInstancesOutput, oldEmail string, identitystoreconn *identitystore.Client) (*string, error) {
-	identityStoreId := ssoInstances.Instances[0].IdentityStoreId
+// ssoUserDiff marks the ID of the SSO user as unknown when the user may change during the apply and checks
+// during the plan that the user exists. A missing user is only logged, as the Account Factory creates users
+// of the built-in directory itself.
+func ssoUserDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" {
+		// A missing user is created by the provider or the Account Factory during the apply.
+		userMayBeCreated := d.Get("sso_user_id").(string) == "" && (d.HasChange("sso.0.create_user_if_missing") || d.HasChanges(accountFactoryAttributes...))
+		if !d.HasChange("sso.0.email") && !userMayBeCreated {
+			return nil
+		}
+		if err := d.SetNewComputed("sso_user_id"); err != nil {
+			return err
+		}
+	}
+
+	if !d.NewValueKnown("sso.0.email") || !d.NewValueKnown("sso.0.create_user_if_missing") {
+		return nil
+	}
+
+	sso := d.Get("sso").([]interface{})
+	if len(sso) == 0 || sso[0] == nil {
+		return nil
+	}
+	ssoMap := sso[0].(map[string]interface{})
+	if ssoMap["create_user_if_missing"].(bool) {
+		return nil
+	}
+
+	// The check is informational only, so it does not require IAM Identity Center permissions either.
+	client := meta.(*providerClient)
 
+	email := ssoMap["email"].(string)
+	userId, err := findSSOUserId(ctx, client, expandSSOInstance(d, client.ssoInstance), email)
+	if err != nil {
+		log.Printf("[WARN] Error checking whether SSO user %s exists: %v", email, err)
+		return nil
+	}
+	if userId == "" {
+		log.Printf("[WARN] SSO user %s does not exist in the Identity Store, it has to be created by the Account Factory or enable create_user_if_missing", email)
+	}
+
+	return nil
+}
+
+func findPrincipalUserId(ctx context.Context, identityStoreId *string, oldEmail string, identitystoreconn *identitystore.Client) (*string, error) {
 	alternateIdentifier := &types.AlternateIdentifierMemberUniqueAttribute{
 		Value: types.UniqueAttribute{
 			AttributePath:  aws.String("UserName"),
@@ -523,10 +833,14 @@ func findPrincipalUserId(ctx context.Context, ssoInstances *ssoadmin.ListInstanc
 		AlternateIdentifier: alternateIdentifier,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("error getting principal id: %v", err)
+		return nil, fmt.Errorf("error getting principal id: %w", err)
 	}
 	return principal.UserId, nil
 }
+
+// errPermissionSetNotFound is returned if no permission set with the given name exists.
+var errPermissionSetNotFound = errors.New("permission set not found")
+
 func findPermissionSetArn(ctx context.Context, ssoadminconn *ssoadmin.Client, instanceArn *string, permissionSetName string) (string, error) {
 	paginator := ssoadmin.NewListPermissionSetsPaginator(ssoadminconn, &ssoadmin.ListPermissionSetsInput{
 		InstanceArn: instanceArn,
@@ -549,7 +863,7 @@ func findPermissionSetArn(ctx context.Context, ssoadminconn *ssoadmin.Client, in
 			}
 		}
 	}
-	return "", fmt.Errorf("permission set not found")
+	return "", errPermissionSetNotFound
 }
 func resourceAWSAccountDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	// Create context with configured timeout
@@ -557,10 +871,10 @@ func resourceAWSAccountDelete(ctx context.Context, d *schema.ResourceData, m int
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	cfg := m.(aws.Config)
+	client := m.(*providerClient)
 
-	scconn := servicecatalog.NewFromConfig(cfg)
-	organizationsconn := organizations.NewFromConfig(cfg)
+	scconn := client.scconn
+	organizationsconn := client.organizationsconn
 
 	name := d.Get("name").(string)
 
@@ -572,18 +886,29 @@ func resourceAWSAccountDelete(ctx context.Context, d *schema.ResourceData, m int
 		return diag.Errorf("error describing provisioned product: %s", err)
 	}
 
-	accountMutex.Lock()
-	defer accountMutex.Unlock()
+	release, err := client.provisioningLock.acquire(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
+
+	// The token stays the same across retries, so a retried call does not terminate twice.
+	terminateToken := aws.String(idempotencyToken())
 
-	account, err := scconn.TerminateProvisionedProduct(ctx, &servicecatalog.TerminateProvisionedProductInput{
-		ProvisionedProductId: aws.String(d.Id()),
+	var account *servicecatalog.TerminateProvisionedProductOutput
+	err = retryOnConflict(ctx, name, func() error {
+		account, err = scconn.TerminateProvisionedProduct(ctx, &servicecatalog.TerminateProvisionedProductInput{
+			ProvisionedProductId: aws.String(d.Id()),
+			TerminateToken:       terminateToken,
+		})
+		return err
 	})
 	if err != nil {
 		return diag.Errorf("error deleting provisioned account %s: %s", name, err)
 	}
 
 	// Wait for the provisioning to finish.
-	_, diags := waitForProvisioning(ctx, name, account.RecordDetail.RecordId, scconn)
+	_, diags := waitForProvisioning(ctx, name, account.RecordDetail.RecordId, scconn, expandPollingConfig(d.Get("provisioning_polling").([]interface{}), rawPollingConfig(d), client.polling))
 	if diags.HasError() {
 		return diags
 	}
@@ -620,17 +945,18 @@ func resourceAWSAccountDelete(ctx context.Context, d *schema.ResourceData, m int
 }
 
 func resourceAWSAccountImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-	// Get account ID from import command
-	accountID := d.Id()
+	// Get account ID and optionally the group ID from import command
+	accountID, importGroupId, _ := strings.Cut(d.Id(), ",")
+	d.SetId(accountID)
 
 	// Set up AWS clients
-	cfg := meta.(aws.Config)
-	scconn := servicecatalog.NewFromConfig(cfg)
-	ssoadminconn := ssoadmin.NewFromConfig(cfg)
-	identitystoreconn := identitystore.NewFromConfig(cfg)
+	client := meta.(*providerClient)
+	scconn := client.scconn
+	ssoadminconn := client.ssoadminconn
+	identitystoreconn := client.identitystoreconn
 
 	// Find the Control Tower Account Factory product
-	productId, _, err := findServiceCatalogAccountProductId(ctx, scconn)
+	productId, _, err := client.findAccountFactoryProduct(ctx, client.accountFactoryProduct)
 	if err != nil {
 		return nil, fmt.Errorf("error finding Control Tower Account Factory product: %w", err)
 	}
@@ -698,13 +1024,13 @@ func resourceAWSAccountImportState(ctx context.Context, d *schema.ResourceData,
 	}
 
 	// Get SSO instance details
-	ssoInstances, err := ssoadminconn.ListInstances(ctx, &ssoadmin.ListInstancesInput{})
-	if err != nil || len(ssoInstances.Instances) == 0 {
-		return nil, fmt.Errorf("error retrieving SSO instances or no instances found: %w", err)
+	instance, err := client.findSSOInstance(ctx, client.ssoInstance)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving SSO instance: %w", err)
 	}
 
-	instanceArn := ssoInstances.Instances[0].InstanceArn
-	identityStoreId := ssoInstances.Instances[0].IdentityStoreId
+	instanceArn := instance.InstanceArn
+	identityStoreId := instance.IdentityStoreId
 
 	// Find the user by email using various attribute paths
 	var user *types.User
@@ -750,63 +1076,91 @@ func resourceAWSAccountImportState(ctx context.Context, d *schema.ResourceData,
 			}
 
 			if userId != nil {
-				// Get all permission sets
-				permissionSets, err := ssoadminconn.ListPermissionSets(ctx, &ssoadmin.ListPermissionSetsInput{
+				foundPermissionSets := []string{}
+				var groupAssignments []ssoTypes.AccountAssignment
+
+				// For each permission set, check if user has an assignment
+				permissionSetsPaginator := ssoadmin.NewListPermissionSetsPaginator(ssoadminconn, &ssoadmin.ListPermissionSetsInput{
 					InstanceArn: instanceArn,
 				})
-				if err == nil {
-					foundPermissionSets := []string{}
+			permissionSets:
+				for permissionSetsPaginator.HasMorePages() {
+					permissionSets, err := permissionSetsPaginator.NextPage(ctx)
+					if err != nil {
+						return nil, fmt.Errorf("error listing permission sets: %w", err)
+					}
 
-					// For each permission set, check if user has an assignment
 					for _, permissionSetArn := range permissionSets.PermissionSets {
-						assignments, err := ssoadminconn.ListAccountAssignments(ctx, &ssoadmin.ListAccountAssignmentsInput{
+						assignmentsPaginator := ssoadmin.NewListAccountAssignmentsPaginator(ssoadminconn, &ssoadmin.ListAccountAssignmentsInput{
 							AccountId:        aws.String(accountID),
 							InstanceArn:      instanceArn,
-							PermissionSetArn: &permissionSetArn,
+							PermissionSetArn: aws.String(permissionSetArn),
 						})
-						if err != nil {
-							continue
-						}
+						for assignmentsPaginator.HasMorePages() {
+							assignments, err := assignmentsPaginator.NextPage(ctx)
+							if err != nil {
+								return nil, fmt.Errorf("error listing account assignments of account %s: %w", accountID, err)
+							}
 
-						for _, assignment := range assignments.AccountAssignments {
-							if assignment.PrincipalType == "USER" && *assignment.PrincipalId == *userId {
-								// Get permission set name
-								permSet, err := ssoadminconn.DescribePermissionSet(ctx, &ssoadmin.DescribePermissionSetInput{
-									InstanceArn:      instanceArn,
-									PermissionSetArn: &permissionSetArn,
-								})
-								if err == nil && permSet.PermissionSet != nil && permSet.PermissionSet.Name != nil {
-									permSetName := *permSet.PermissionSet.Name
-									foundPermissionSets = append(foundPermissionSets, permSetName)
-
-									// Prioritize matching the default permission set
-									if permSetName == defaultPermissionSetName {
-										ssoMap["permission_set_name"] = permSetName
-										break
-									}
+							for _, assignment := range assignments.AccountAssignments {
+								// Remember the groups, in case the user has no assignment
+								if assignment.PrincipalType == ssoTypes.PrincipalTypeGroup {
+									groupAssignments = append(groupAssignments, assignment)
+								}
 
-									// Otherwise use the first permission set found
-									if _, exists := ssoMap["permission_set_name"]; !exists {
-										ssoMap["permission_set_name"] = permSetName
+								if assignment.PrincipalType == ssoTypes.PrincipalTypeUser && aws.ToString(assignment.PrincipalId) == *userId {
+									// Get permission set name
+									permSet, err := ssoadminconn.DescribePermissionSet(ctx, &ssoadmin.DescribePermissionSetInput{
+										InstanceArn:      instanceArn,
+										PermissionSetArn: aws.String(permissionSetArn),
+									})
+									if err == nil && permSet.PermissionSet != nil && permSet.PermissionSet.Name != nil {
+										permSetName := *permSet.PermissionSet.Name
+										foundPermissionSets = append(foundPermissionSets, permSetName)
+
+										// Otherwise use the first permission set found
+										if _, exists := ssoMap["permission_set_name"]; !exists {
+											ssoMap["permission_set_name"] = permSetName
+										}
+
+										// Prioritize matching the default permission set
+										if permSetName == defaultPermissionSetName && importGroupId == "" {
+											ssoMap["permission_set_name"] = permSetName
+											break permissionSets
+										}
 									}
 								}
 							}
 						}
+					}
+				}
 
-						// If we found the default permission set, stop searching
-						if permSetName, exists := ssoMap["permission_set_name"]; exists &&
-							permSetName.(string) == defaultPermissionSetName {
-							break
-						}
+				// Log for multiple permission sets without hard-coding values
+				if len(foundPermissionSets) > 1 {
+					selectedSet := ssoMap["permission_set_name"].(string)
+					fmt.Printf("[INFO] User has %d permission sets assigned: %v. Selected: '%s'.\n",
+						len(foundPermissionSets),
+						strings.Join(foundPermissionSets, ", "),
+						selectedSet)
+				}
+
+				// Fall back to the group assignment, or use the group selected in the import ID
+				if _, exists := ssoMap["permission_set_name"]; !exists || importGroupId != "" {
+					groupAssignment, err := selectImportGroupAssignment(accountID, groupAssignments, importGroupId)
+					if err != nil {
+						return nil, err
 					}
 
-					// Log for multiple permission sets without hard-coding values
-					if len(foundPermissionSets) > 1 {
-						selectedSet := ssoMap["permission_set_name"].(string)
-						fmt.Printf("[INFO] User has %d permission sets assigned: %v. Selected: '%s'.\n",
-							len(foundPermissionSets),
-							strings.Join(foundPermissionSets, ", "),
-							selectedSet)
+					if groupAssignment != nil {
+						permSet, err := ssoadminconn.DescribePermissionSet(ctx, &ssoadmin.DescribePermissionSetInput{
+							InstanceArn:      instanceArn,
+							PermissionSetArn: groupAssignment.PermissionSetArn,
+						})
+						if err != nil {
+							return nil, fmt.Errorf("error describing permission set %s: %w", aws.ToString(groupAssignment.PermissionSetArn), err)
+						}
+						ssoMap["permission_set_name"] = aws.ToString(permSet.PermissionSet.Name)
+						ssoMap["group_id"] = aws.ToString(groupAssignment.PrincipalId)
 					}
 				}
 			}
@@ -830,76 +1184,179 @@ func resourceAWSAccountImportState(ctx context.Context, d *schema.ResourceData,
 	if err := d.Set("sso", []interface{}{ssoMap}); err != nil {
 		return nil, fmt.Errorf("error setting SSO values: %w", err)
 	}
+	if err := d.Set("sso_user_id", aws.ToString(userId)); err != nil {
+		return nil, fmt.Errorf("error setting SSO user ID: %w", err)
+	}
 
 	// Let the Read function handle the rest
 	return schema.ImportStatePassthroughContext(ctx, d, meta)
 }
 
+// selectImportGroupAssignment returns the assignment of the group selected in the import ID or, if no group
+// was selected, the only group assignment of the account. Several group assignments cannot be told apart.
+func selectImportGroupAssignment(accountId string, assignments []ssoTypes.AccountAssignment, groupId string) (*ssoTypes.AccountAssignment, error) {
+	var candidates []ssoTypes.AccountAssignment
+	for _, assignment := range assignments {
+		if groupId == "" || aws.ToString(assignment.PrincipalId) == groupId {
+			candidates = append(candidates, assignment)
+		}
+	}
+
+	switch {
+	case len(candidates) == 1:
+		return &candidates[0], nil
+	case len(candidates) == 0 && groupId != "":
+		return nil, fmt.Errorf("group %s has no assignment on account %s", groupId, accountId)
+	case len(candidates) == 0:
+		return nil, nil
+	default:
+		descriptions := make([]string, 0, len(candidates))
+		for _, candidate := range candidates {
+			descriptions = append(descriptions, fmt.Sprintf("%s (%s)", aws.ToString(candidate.PrincipalId), aws.ToString(candidate.PermissionSetArn)))
+		}
+		return nil, fmt.Errorf("found %d group assignments on account %s: %s, import the account as %s,<group_id> with a group that has a single assignment to select one", len(candidates), accountId, strings.Join(descriptions, ", "), accountId)
+	}
+}
+
+// accountRecordOutputs are the outputs of an Account Factory provisioning record.
+type accountRecordOutputs struct {
+	accountId    string
+	accountEmail string
+	ssoUserEmail string
+}
+
+// parseAccountRecordOutputs extracts the account details from the outputs of a provisioning record.
+func parseAccountRecordOutputs(recordOutputs []scTypes.RecordOutput) accountRecordOutputs {
+	var outputs accountRecordOutputs
+
+	for _, output := range recordOutputs {
+		if output.OutputKey == nil || output.OutputValue == nil {
+			continue
+		}
+
+		switch *output.OutputKey {
+		case "AccountEmail":
+			outputs.accountEmail = *output.OutputValue
+		case "AccountId":
+			outputs.accountId = *output.OutputValue
+		case "SSOUserEmail":
+			outputs.ssoUserEmail = *output.OutputValue
+		}
+	}
+
+	return outputs
+}
+
 // waitForProvisioning waits until the provisioning finished.
-func waitForProvisioning(ctx context.Context, name string, recordID *string, client *servicecatalog.Client) (*servicecatalog.DescribeRecordOutput, diag.Diagnostics) {
-	var (
-		status *servicecatalog.DescribeRecordOutput
-		diags  diag.Diagnostics
-	)
+func waitForProvisioning(ctx context.Context, name string, recordID *string, client *servicecatalog.Client, polling pollingConfig) (*servicecatalog.DescribeRecordOutput, diag.Diagnostics) {
+	var status *servicecatalog.DescribeRecordOutput
 
 	record := &servicecatalog.DescribeRecordInput{
 		Id: recordID,
 	}
 
-	for {
-		// Check if context has been cancelled (timeout reached)
-		select {
-		case <-ctx.Done():
-			return status, diag.Errorf("timeout reached while waiting for account %s provisioning: %v", name, ctx.Err())
-		default:
-		}
-
+	err := waitForOperation(ctx, fmt.Sprintf("account %s provisioning", name), polling, func() (bool, error) {
 		// Get the provisioning status.
 		var err error
 		status, err = client.DescribeRecord(ctx, record)
 		if err != nil {
-			return status, diag.Errorf("error reading provisioning status of account %s: %s", name, err)
+			return false, fmt.Errorf("error reading provisioning status of account %s: %s", name, err)
 		}
 
-		// If the provisioning succeeded we are done.
-		if status.RecordDetail.Status == scTypes.RecordStatusSucceeded {
-			break
-		}
-
-		// If the provisioning failed we try to cleanup the tainted account.
-		if status.RecordDetail.Status == scTypes.RecordStatusFailed {
+		switch status.RecordDetail.Status {
+		case scTypes.RecordStatusSucceeded:
+			return true, nil
+		case scTypes.RecordStatusFailed:
+			// If the provisioning failed we try to cleanup the tainted account.
 			if len(status.RecordDetail.RecordErrors) > 0 {
-				return status, diag.Errorf("provisioning account %s failed: %s", name, *status.RecordDetail.RecordErrors[0].Description)
+				return false, fmt.Errorf("provisioning account %s failed: %s", name, *status.RecordDetail.RecordErrors[0].Description)
 			}
-			return status, diag.Errorf("provisioning account %s failed with unknown error", name)
+			return false, fmt.Errorf("provisioning account %s failed with unknown error", name)
 		}
 
-		// Wait 5 seconds before checking the status again, but respect context cancellation
-		timer := time.NewTimer(5 * time.Second)
-		select {
-		case <-ctx.Done():
-			timer.Stop()
-			return status, diag.Errorf("timeout reached while waiting for account %s provisioning: %v", name, ctx.Err())
-		case <-timer.C:
-			// Continue the loop
-		}
+		return false, nil
+	})
+	if err != nil {
+		return status, diag.FromErr(err)
 	}
 
-	return status, diags
+	return status, nil
 }
 
-func findServiceCatalogAccountProductId(ctx context.Context, client *servicecatalog.Client) (*string, *string, error) {
-	products, err := client.SearchProducts(ctx, &servicecatalog.SearchProductsInput{
-		Filters: map[string][]string{"FullTextSearch": {"AWS Control Tower Account Factory"}},
-	})
-	if err != nil {
-		return nil, nil, fmt.Errorf("error occurred while searching for the account product: %w", err)
+// resourceGetter is implemented by both schema.ResourceData and schema.ResourceDiff.
+type resourceGetter interface {
+	Get(key string) interface{}
+	GetOk(key string) (interface{}, bool)
+}
+
+// accountFactoryProduct describes how the Account Factory product is looked up in Service Catalog.
+type accountFactoryProduct struct {
+	// id pins the product, the name is not used in that case.
+	id string
+	// name is searched for, defaults to defaultAccountFactoryProductName.
+	name string
+	// exactName only accepts products whose name equals name, instead of using the full-text search result.
+	exactName bool
+}
+
+const defaultAccountFactoryProductName = "AWS Control Tower Account Factory"
+
+// expandAccountFactoryProduct applies the Account Factory product settings of a resource on top of the provider defaults.
+func expandAccountFactoryProduct(d resourceGetter, defaults accountFactoryProduct) accountFactoryProduct {
+	product := defaults
+
+	if v, ok := d.GetOk("account_factory_product_id"); ok {
+		product.id = v.(string)
+		product.name = ""
 	}
-	if len(products.ProductViewSummaries) != 1 {
-		return nil, nil, fmt.Errorf("unexpected number of search results: %d", len(products.ProductViewSummaries))
+	if v, ok := d.GetOk("account_factory_product_name"); ok {
+		product.id = ""
+		product.name = v.(string)
 	}
+	if v, ok := d.GetOk("account_factory_product_name_match"); ok {
+		product.exactName = v.(string) == "exact"
+	}
+
+	return product
+}
+
+func findServiceCatalogAccountProductId(ctx context.Context, client *servicecatalog.Client, product accountFactoryProduct) (*string, *string, error) {
+	productId := aws.String(product.id)
+
+	if product.id == "" {
+		name := product.name
+		if name == "" {
+			name = defaultAccountFactoryProductName
+		}
+
+		var (
+			matches []scTypes.ProductViewSummary
+			names   []string
+		)
+
+		paginator := servicecatalog.NewSearchProductsPaginator(client, &servicecatalog.SearchProductsInput{
+			Filters: map[string][]string{"FullTextSearch": {name}},
+		})
+		for paginator.HasMorePages() {
+			products, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error occurred while searching for the account product: %w", err)
+			}
 
-	productId := products.ProductViewSummaries[0].ProductId
+			for _, summary := range products.ProductViewSummaries {
+				names = append(names, aws.ToString(summary.Name))
+				if !product.exactName || aws.ToString(summary.Name) == name {
+					matches = append(matches, summary)
+				}
+			}
+		}
+
+		if len(matches) != 1 {
+			return nil, nil, fmt.Errorf("unexpected number of search results for account product %q: %d (found: %s), consider setting account_factory_product_id or an exact account_factory_product_name_match", name, len(matches), strings.Join(names, ", "))
+		}
+
+		productId = matches[0].ProductId
+	}
 
 	artifacts, err := client.ListProvisioningArtifacts(ctx, &servicecatalog.ListProvisioningArtifactsInput{
 		ProductId: productId,
@@ -922,8 +1379,67 @@ func findServiceCatalogAccountProductId(ctx context.Context, client *servicecata
 
 	return productId, artifactID, nil
 }
-func findParentOrganizationalUnit(ctx context.Context, client *organizations.Client, identifier string) (*orgTypes.OrganizationalUnit, error) {
-	paginator := organizations.NewListParentsPaginator(client, &organizations.ListParentsInput{
+func findProvisioningArtifactIdByName(ctx context.Context, client *servicecatalog.Client, productId *string, name string) (*string, error) {
+	artifacts, err := client.ListProvisioningArtifacts(ctx, &servicecatalog.ListProvisioningArtifactsInput{
+		ProductId: productId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing provisioning artifacts: %w", err)
+	}
+
+	for _, artifact := range artifacts.ProvisioningArtifactDetails {
+		if aws.ToString(artifact.Name) == name {
+			return artifact.Id, nil
+		}
+	}
+
+	return nil, fmt.Errorf("could not find a provisioning artifact named %q for product %s", name, aws.ToString(productId))
+}
+
+// provisioningArtifactDiff plans a change of the provisioning artifact of an existing account,
+// when it is pinned by name or an upgrade to the active artifact was requested.
+func provisioningArtifactDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	// An artifact pinned by ID is handled by the regular diff.
+	if !d.GetRawConfig().GetAttr("provisioning_artifact_id").IsNull() {
+		return nil
+	}
+
+	name, pinnedByName := d.GetOk("provisioning_artifact_name")
+	if !pinnedByName && !d.Get("upgrade_provisioning_artifact").(bool) {
+		return nil
+	}
+
+	client := meta.(*providerClient)
+	scconn := client.scconn
+
+	productId, artifactId, err := client.findAccountFactoryProduct(ctx, expandAccountFactoryProduct(d, client.accountFactoryProduct))
+	if err != nil {
+		return err
+	}
+
+	if pinnedByName {
+		artifactId, err = findProvisioningArtifactIdByName(ctx, scconn, productId, name.(string))
+		if err != nil {
+			return err
+		}
+	}
+
+	if aws.ToString(artifactId) == d.Get("provisioning_artifact_id").(string) {
+		return nil
+	}
+
+	return d.SetNew("provisioning_artifact_id", aws.ToString(artifactId))
+}
+
+// errNoParentOrganizationalUnit is returned for accounts directly below the organization root.
+var errNoParentOrganizationalUnit = errors.New("no OU parent found")
+
+func findParentOrganizationalUnit(ctx context.Context, client *providerClient, identifier string) (*orgTypes.OrganizationalUnit, error) {
+	paginator := organizations.NewListParentsPaginator(client.organizationsconn, &organizations.ListParentsInput{
 		ChildId: aws.String(identifier),
 	})
 
@@ -946,17 +1462,10 @@ func findParentOrganizationalUnit(ctx context.Context, client *organizations.Cli
 	}
 
 	if parentOuId == "" {
-		return nil, fmt.Errorf("no OU parent found for %s", identifier)
-	}
-
-	ouOutput, err := client.DescribeOrganizationalUnit(ctx, &organizations.DescribeOrganizationalUnitInput{
-		OrganizationalUnitId: aws.String(parentOuId),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("error describing parent OU %s: %w", parentOuId, err)
+		return nil, fmt.Errorf("%w for %s", errNoParentOrganizationalUnit, identifier)
 	}
 
-	return ouOutput.OrganizationalUnit, nil
+	return client.describeOrganizationalUnit(ctx, parentOuId)
 }
 
 func findParentOrganizationRootId(ctx context.Context, client *organizations.Client, identifier string) (string, error) {
@@ -995,21 +1504,31 @@ func toOrganizationsTags(tags map[string]interface{}) []orgTypes.Tag {
 	return result
 }
 
-func fromOrganizationTags(tags []orgTypes.Tag) map[string]*string {
+func fromOrganizationTags(tags []orgTypes.Tag, ignoreTags *ignoreTagsConfig) map[string]*string {
 	m := make(map[string]*string, len(tags))
 
 	for _, tag := range tags {
+		if ignoreTags.ignored(*tag.Key) {
+			continue
+		}
 		m[*tag.Key] = tag.Value
 	}
 
 	return m
 }
 
-func updateAccountTags(ctx context.Context, client *organizations.Client, identifier string, oldTags interface{}, newTags interface{}) error {
+func updateAccountTags(ctx context.Context, client *organizations.Client, identifier string, oldTags interface{}, newTags interface{}, ignoreTags *ignoreTagsConfig) error {
 	oldTagsMap := oldTags.(map[string]interface{})
 	newTagsMap := newTags.(map[string]interface{})
 
-	if removedTags := removedTags(oldTagsMap, newTagsMap); len(removedTags) > 0 {
+	removedTags := removedTags(oldTagsMap, newTagsMap)
+	for k := range removedTags {
+		if ignoreTags.ignored(k) {
+			delete(removedTags, k)
+		}
+	}
+
+	if len(removedTags) > 0 {
 		input := &organizations.UntagResourceInput{
 			ResourceId: aws.String(identifier),
 			TagKeys:    keys(removedTags),
@@ -0,0 +1,187 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ssoTypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestSelectImportGroupAssignment(t *testing.T) {
+	admins := ssoTypes.AccountAssignment{
+		PrincipalType:    ssoTypes.PrincipalTypeGroup,
+		PrincipalId:      aws.String("group-admins"),
+		PermissionSetArn: aws.String("arn:aws:sso:::permissionSet/ssoins-1111111111111111/ps-admin"),
+	}
+	readers := ssoTypes.AccountAssignment{
+		PrincipalType:    ssoTypes.PrincipalTypeGroup,
+		PrincipalId:      aws.String("group-readers"),
+		PermissionSetArn: aws.String("arn:aws:sso:::permissionSet/ssoins-1111111111111111/ps-read"),
+	}
+
+	if assignment, err := selectImportGroupAssignment("123456789012", nil, ""); err != nil || assignment != nil {
+		t.Errorf("expected no assignment without groups, got %v (%v)", assignment, err)
+	}
+
+	if assignment, err := selectImportGroupAssignment("123456789012", []ssoTypes.AccountAssignment{admins}, ""); err != nil || aws.ToString(assignment.PrincipalId) != "group-admins" {
+		t.Errorf("expected the only group assignment, got %v (%v)", assignment, err)
+	}
+
+	if _, err := selectImportGroupAssignment("123456789012", []ssoTypes.AccountAssignment{admins, readers}, ""); err == nil {
+		t.Errorf("expected error for several group assignments")
+	}
+
+	if assignment, err := selectImportGroupAssignment("123456789012", []ssoTypes.AccountAssignment{admins, readers}, "group-readers"); err != nil || aws.ToString(assignment.PrincipalId) != "group-readers" {
+		t.Errorf("expected the selected group assignment, got %v (%v)", assignment, err)
+	}
+
+	if _, err := selectImportGroupAssignment("123456789012", []ssoTypes.AccountAssignment{admins}, "group-unknown"); err == nil {
+		t.Errorf("expected error for a group without assignment")
+	}
+}
+
+// accountFactoryUpdateNeeded returns whether changing the sso block of an account to the given settings
+// requires an update of the provisioned product.
+func accountFactoryUpdateNeeded(t *testing.T, sso map[string]interface{}) bool {
+	t.Helper()
+
+	// The diff is computed without the CustomizeDiff functions, which call the AWS APIs.
+	r := resourceAWSAccount()
+	r.CustomizeDiff = nil
+
+	d := r.TestResourceData()
+	d.SetId("pp-abcdefghijklm")
+	for key, value := range map[string]interface{}{
+		"name":                "Example",
+		"email":               "aws+example@example.com",
+		"organizational_unit": "Sandbox",
+		"sso": []interface{}{
+			map[string]interface{}{
+				"first_name":          "Jane",
+				"last_name":           "Doe",
+				"email":               "jane.doe@example.com",
+				"permission_set_name": "AWSAdministratorAccess",
+			},
+		},
+	} {
+		if err := d.Set(key, value); err != nil {
+			t.Fatalf("unexpected error setting %s: %v", key, err)
+		}
+	}
+
+	ssoConfig := map[string]interface{}{
+		"first_name": "Jane",
+		"last_name":  "Doe",
+		"email":      "jane.doe@example.com",
+	}
+	for key, value := range sso {
+		ssoConfig[key] = value
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":                "Example",
+		"email":               "aws+example@example.com",
+		"organizational_unit": "Sandbox",
+		"sso":                 []interface{}{ssoConfig},
+	})
+
+	state := d.State()
+	diff, err := r.Diff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatalf("unexpected error computing diff: %v", err)
+	}
+	if diff.Empty() {
+		t.Fatalf("expected a diff for sso settings %v", sso)
+	}
+
+	planned, err := schema.InternalMap(r.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("unexpected error applying diff: %v", err)
+	}
+
+	return planned.HasChanges(accountFactoryAttributes...)
+}
+
+func TestAccountFactoryAttributes(t *testing.T) {
+	for _, sso := range []map[string]interface{}{
+		{"permission_set_name": "AWSReadOnlyAccess"},
+		{"remove_account_assignment_on_update": true},
+		{"create_user_if_missing": true},
+		{"group_name": "Administrators"},
+		{"group_id": "906720a1b2-1a2b3c4d-5e6f-7a8b-9c0d-1e2f3a4b5c6d"},
+	} {
+		if accountFactoryUpdateNeeded(t, sso) {
+			t.Errorf("expected no Account Factory update when changing %v", sso)
+		}
+	}
+
+	for _, sso := range []map[string]interface{}{
+		{"email": "john.doe@example.com"},
+		{"first_name": "John"},
+	} {
+		if !accountFactoryUpdateNeeded(t, sso) {
+			t.Errorf("expected an Account Factory update when changing %v", sso)
+		}
+	}
+}
+
+func TestSSOUserDiff(t *testing.T) {
+	r := resourceAWSAccount()
+	r.CustomizeDiff = ssoUserDiff
+
+	for _, tc := range []struct {
+		userId   string
+		computed bool
+	}{
+		// A missing user is created when create_user_if_missing is enabled.
+		{userId: "", computed: true},
+		{userId: "906720a1b2-1a2b3c4d-5e6f-7a8b-9c0d-1e2f3a4b5c6d", computed: false},
+	} {
+		d := r.TestResourceData()
+		d.SetId("pp-abcdefghijklm")
+		for key, value := range map[string]interface{}{
+			"name":                "Example",
+			"email":               "aws+example@example.com",
+			"organizational_unit": "Sandbox",
+			"sso_user_id":         tc.userId,
+			"sso": []interface{}{
+				map[string]interface{}{
+					"first_name":          "Jane",
+					"last_name":           "Doe",
+					"email":               "jane.doe@example.com",
+					"permission_set_name": "AWSAdministratorAccess",
+				},
+			},
+		} {
+			if err := d.Set(key, value); err != nil {
+				t.Fatalf("unexpected error setting %s: %v", key, err)
+			}
+		}
+
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":                "Example",
+			"email":               "aws+example@example.com",
+			"organizational_unit": "Sandbox",
+			"sso": []interface{}{
+				map[string]interface{}{
+					"first_name":             "Jane",
+					"last_name":              "Doe",
+					"email":                  "jane.doe@example.com",
+					"create_user_if_missing": true,
+				},
+			},
+		})
+
+		diff, err := r.Diff(context.Background(), d.State(), config, nil)
+		if err != nil {
+			t.Fatalf("unexpected error computing diff: %v", err)
+		}
+
+		attr, ok := diff.Attributes["sso_user_id"]
+		if computed := ok && attr.NewComputed; computed != tc.computed {
+			t.Errorf("expected sso_user_id computed to be %t for user ID %q, got %v", tc.computed, tc.userId, diff)
+		}
+	}
+}
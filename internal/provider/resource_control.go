@@ -0,0 +1,502 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/controltower"
+	"github.com/aws/aws-sdk-go-v2/service/controltower/document"
+	ctTypes "github.com/aws/aws-sdk-go-v2/service/controltower/types"
+	smithydocument "github.com/aws/smithy-go/document"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceControl() *schema.Resource {
+	return &schema.Resource{
+		Description: "Enables a Control Tower control (guardrail) on an Organizational Unit.",
+
+		CreateContext: resourceControlCreate,
+		ReadContext:   resourceControlRead,
+		UpdateContext: resourceControlUpdate,
+		DeleteContext: resourceControlDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceControlImportState,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"control_identifier": {
+				Description:  "ARN of the control to enable, e.g. `arn:aws:controltower:eu-central-1::control/AWS-GR_RESTRICT_ROOT_USER`.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateARN,
+			},
+			"target_identifier": {
+				Description:  "ARN of the Organizational Unit on which the control is enabled.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateARN,
+			},
+			"parameters": parametersSchema("Parameters of the control."),
+			"tags": {
+				Description: "Key-value map of tags for the enabled control.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"arn": {
+				Description: "ARN of the enabled control.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"status": {
+				Description: "Enablement status of the control, e.g. `SUCCEEDED` or `FAILED`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"drift_status": {
+				Description: "Drift status of the control, e.g. `IN_SYNC` or `DRIFTED`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+			Read:   schema.DefaultTimeout(20 * time.Minute),
+			Update: schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+func resourceControlCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutCreate)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	controltowerconn := client.controltowerconn
+
+	controlIdentifier := d.Get("control_identifier").(string)
+	targetIdentifier := d.Get("target_identifier").(string)
+	name := fmt.Sprintf("control %s on %s", controlIdentifier, targetIdentifier)
+
+	parameters, err := expandControlParameters(d.Get("parameters").(*schema.Set).List())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	params := &controltower.EnableControlInput{
+		ControlIdentifier: aws.String(controlIdentifier),
+		TargetIdentifier:  aws.String(targetIdentifier),
+		Parameters:        parameters,
+	}
+	if tags := toControlTowerTags(d.Get("tags").(map[string]interface{})); len(tags) > 0 {
+		params.Tags = tags
+	}
+
+	var output *controltower.EnableControlOutput
+	err = retryOnConflict(ctx, name, func() error {
+		output, err = controltowerconn.EnableControl(ctx, params)
+		return err
+	})
+	if err != nil {
+		return diag.Errorf("error enabling %s: %v", name, err)
+	}
+
+	// Set the ID so a failed control is disabled again.
+	d.SetId(aws.ToString(output.Arn))
+
+	if err := waitForControlOperation(ctx, name, output.OperationIdentifier, controltowerconn, defaultPollingConfig); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceControlRead(ctx, d, m)
+}
+
+func resourceControlRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutRead)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	controltowerconn := client.controltowerconn
+
+	control, err := controltowerconn.GetEnabledControl(ctx, &controltower.GetEnabledControlInput{
+		EnabledControlIdentifier: aws.String(d.Id()),
+	})
+	if !d.IsNewResource() {
+		var notFoundErr *ctTypes.ResourceNotFoundException
+		if errors.As(err, &notFoundErr) {
+			d.SetId("")
+			return nil
+		}
+	}
+	if err != nil {
+		return diag.Errorf("error reading enabled control %s: %v", d.Id(), err)
+	}
+
+	details := control.EnabledControlDetails
+	if err := d.Set("arn", details.Arn); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("control_identifier", details.ControlIdentifier); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("target_identifier", details.TargetIdentifier); err != nil {
+		return diag.FromErr(err)
+	}
+
+	parameters, err := flattenControlParameters(details.Parameters)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("parameters", parameters); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var status, driftStatus string
+	if details.StatusSummary != nil {
+		status = string(details.StatusSummary.Status)
+	}
+	if details.DriftStatusSummary != nil {
+		driftStatus = string(details.DriftStatusSummary.DriftStatus)
+	}
+	if err := d.Set("status", status); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("drift_status", driftStatus); err != nil {
+		return diag.FromErr(err)
+	}
+
+	tags, err := controltowerconn.ListTagsForResource(ctx, &controltower.ListTagsForResourceInput{
+		ResourceArn: aws.String(d.Id()),
+	})
+	if err != nil {
+		return diag.Errorf("error listing tags for resource %s: %v", d.Id(), err)
+	}
+	if err := d.Set("tags", tags.Tags); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceControlUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutUpdate)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	controltowerconn := client.controltowerconn
+
+	name := fmt.Sprintf("control %s on %s", d.Get("control_identifier"), d.Get("target_identifier"))
+
+	if d.HasChange("parameters") {
+		parameters, err := expandControlParameters(d.Get("parameters").(*schema.Set).List())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		var output *controltower.UpdateEnabledControlOutput
+		err = retryOnConflict(ctx, name, func() error {
+			output, err = controltowerconn.UpdateEnabledControl(ctx, &controltower.UpdateEnabledControlInput{
+				EnabledControlIdentifier: aws.String(d.Id()),
+				Parameters:               parameters,
+			})
+			return err
+		})
+		if err != nil {
+			return diag.Errorf("error updating %s: %v", name, err)
+		}
+
+		if err := waitForControlOperation(ctx, name, output.OperationIdentifier, controltowerconn, defaultPollingConfig); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChange("tags") {
+		o, n := d.GetChange("tags")
+
+		if err := updateControlTowerTags(ctx, controltowerconn, d.Id(), o, n); err != nil {
+			return diag.Errorf("error updating tags of %s: %v", name, err)
+		}
+	}
+
+	return resourceControlRead(ctx, d, m)
+}
+
+func resourceControlDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutDelete)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	controltowerconn := client.controltowerconn
+
+	controlIdentifier := d.Get("control_identifier").(string)
+	targetIdentifier := d.Get("target_identifier").(string)
+	name := fmt.Sprintf("control %s on %s", controlIdentifier, targetIdentifier)
+
+	var output *controltower.DisableControlOutput
+	err := retryOnConflict(ctx, name, func() error {
+		var err error
+		output, err = controltowerconn.DisableControl(ctx, &controltower.DisableControlInput{
+			ControlIdentifier: aws.String(controlIdentifier),
+			TargetIdentifier:  aws.String(targetIdentifier),
+		})
+		return err
+	})
+	var notFoundErr *ctTypes.ResourceNotFoundException
+	if errors.As(err, &notFoundErr) {
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("error disabling %s: %v", name, err)
+	}
+
+	if err := waitForControlOperation(ctx, name, output.OperationIdentifier, controltowerconn, defaultPollingConfig); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+// resourceControlImportState accepts the ARN of the enabled control or the target and control identifiers
+// separated by a comma.
+func resourceControlImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	targetIdentifier, controlIdentifier, ok := parseControlImportId(d.Id())
+	if !ok {
+		return schema.ImportStatePassthroughContext(ctx, d, meta)
+	}
+
+	client := meta.(*providerClient)
+
+	paginator := controltower.NewListEnabledControlsPaginator(client.controltowerconn, &controltower.ListEnabledControlsInput{
+		TargetIdentifier: aws.String(targetIdentifier),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error listing enabled controls of %s: %w", targetIdentifier, err)
+		}
+
+		for _, control := range output.EnabledControls {
+			if aws.ToString(control.ControlIdentifier) == controlIdentifier {
+				d.SetId(aws.ToString(control.Arn))
+				return []*schema.ResourceData{d}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("control %s is not enabled on %s", controlIdentifier, targetIdentifier)
+}
+
+// parseControlImportId splits an import ID of the form <target_identifier>,<control_identifier>.
+func parseControlImportId(id string) (string, string, bool) {
+	targetIdentifier, controlIdentifier, ok := strings.Cut(id, ",")
+	if !ok || targetIdentifier == "" || controlIdentifier == "" {
+		return "", "", false
+	}
+
+	return targetIdentifier, controlIdentifier, true
+}
+
+func expandControlParameters(l []interface{}) ([]ctTypes.EnabledControlParameter, error) {
+	parameters := make([]ctTypes.EnabledControlParameter, 0, len(l))
+
+	for _, v := range l {
+		m := v.(map[string]interface{})
+
+		value, err := decodeParameterValue(m["key"].(string), m["value"].(string))
+		if err != nil {
+			return nil, err
+		}
+
+		parameters = append(parameters, ctTypes.EnabledControlParameter{
+			Key:   aws.String(m["key"].(string)),
+			Value: value,
+		})
+	}
+
+	return parameters, nil
+}
+
+func flattenControlParameters(parameters []ctTypes.EnabledControlParameterSummary) ([]interface{}, error) {
+	result := make([]interface{}, 0, len(parameters))
+
+	for _, parameter := range parameters {
+		value, err := encodeParameterValue(aws.ToString(parameter.Key), parameter.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, map[string]interface{}{
+			"key":   aws.ToString(parameter.Key),
+			"value": value,
+		})
+	}
+
+	return result, nil
+}
+
+// parametersSchema returns the schema of the parameters of controls and baselines, whose values are JSON encoded.
+func parametersSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Description: description,
+		Type:        schema.TypeSet,
+		Optional:    true,
+		Set:         parameterHash,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"key": {
+					Description: "Key of the parameter.",
+					Type:        schema.TypeString,
+					Required:    true,
+				},
+				"value": {
+					Description:  "JSON encoded value of the parameter, e.g. `jsonencode([\"eu-central-1\"])`.",
+					Type:         schema.TypeString,
+					Required:     true,
+					ValidateFunc: validation.StringIsJSON,
+					StateFunc: func(v interface{}) string {
+						json, _ := structure.NormalizeJsonString(v)
+						return json
+					},
+				},
+			},
+		},
+	}
+}
+
+// parameterHash hashes a parameter by its key and normalized value. The default hash uses the configured
+// value, so values that only differ in formatting would be different elements of the set.
+func parameterHash(v interface{}) int {
+	m := v.(map[string]interface{})
+
+	// Values that are not JSON, e.g. unknown values during the plan, are hashed as they are.
+	value, _ := structure.NormalizeJsonString(m["value"])
+
+	return schema.HashString(m["key"].(string) + "=" + value)
+}
+
+// decodeParameterValue converts the JSON encoded value of a parameter into a document.
+func decodeParameterValue(key string, value string) (document.Interface, error) {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return nil, fmt.Errorf("error decoding value of parameter %s: %w", key, err)
+	}
+
+	return document.NewLazyDocument(decoded), nil
+}
+
+// encodeParameterValue converts the document value of a parameter into JSON.
+func encodeParameterValue(key string, value document.Interface) (string, error) {
+	var decoded interface{}
+	if value != nil {
+		if err := value.UnmarshalSmithyDocument(&decoded); err != nil {
+			return "", fmt.Errorf("error decoding value of parameter %s: %w", key, err)
+		}
+	}
+
+	encoded, err := json.Marshal(jsonNumbers(decoded))
+	if err != nil {
+		return "", fmt.Errorf("error encoding value of parameter %s: %w", key, err)
+	}
+
+	// The value must match the normalized configuration, see parametersSchema.
+	return structure.NormalizeJsonString(string(encoded))
+}
+
+// jsonNumbers replaces the numbers of a decoded document, which would be encoded as strings, with JSON numbers.
+func jsonNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case smithydocument.Number:
+		return json.Number(v)
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonNumbers(e)
+		}
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonNumbers(e)
+		}
+	}
+
+	return v
+}
+
+func toControlTowerTags(tags map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(tags))
+
+	for k, v := range tags {
+		result[k] = v.(string)
+	}
+
+	return result
+}
+
+func updateControlTowerTags(ctx context.Context, client *controltower.Client, identifier string, oldTags interface{}, newTags interface{}) error {
+	oldTagsMap := oldTags.(map[string]interface{})
+	newTagsMap := newTags.(map[string]interface{})
+
+	if removedTags := removedTags(oldTagsMap, newTagsMap); len(removedTags) > 0 {
+		_, err := client.UntagResource(ctx, &controltower.UntagResourceInput{
+			ResourceArn: aws.String(identifier),
+			TagKeys:     keys(removedTags),
+		})
+		if err != nil {
+			return fmt.Errorf("error untagging resource (%s): %w", identifier, err)
+		}
+	}
+
+	if updatedTags := updatedTags(oldTagsMap, newTagsMap); len(updatedTags) > 0 {
+		_, err := client.TagResource(ctx, &controltower.TagResourceInput{
+			ResourceArn: aws.String(identifier),
+			Tags:        toControlTowerTags(updatedTags),
+		})
+		if err != nil {
+			return fmt.Errorf("error tagging resource (%s): %w", identifier, err)
+		}
+	}
+
+	return nil
+}
+
+// waitForControlOperation waits until the Control Tower control operation finished.
+func waitForControlOperation(ctx context.Context, name string, operationId *string, client *controltower.Client, polling pollingConfig) error {
+	return waitForOperation(ctx, name, polling, func() (bool, error) {
+		output, err := client.GetControlOperation(ctx, &controltower.GetControlOperationInput{
+			OperationIdentifier: operationId,
+		})
+		if err != nil {
+			return false, fmt.Errorf("error reading operation status of %s: %w", name, err)
+		}
+
+		switch output.ControlOperation.Status {
+		case ctTypes.ControlOperationStatusSucceeded:
+			return true, nil
+		case ctTypes.ControlOperationStatusFailed:
+			return false, fmt.Errorf("operation of %s failed: %s", name, aws.ToString(output.ControlOperation.StatusMessage))
+		}
+
+		return false, nil
+	})
+}
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/controltower/document"
+	ctTypes "github.com/aws/aws-sdk-go-v2/service/controltower/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestParseControlImportId(t *testing.T) {
+	cases := []struct {
+		id                string
+		targetIdentifier  string
+		controlIdentifier string
+		ok                bool
+	}{
+		{
+			id:                "arn:aws:organizations::123456789012:ou/o-abcdefghij/ou-abcd-12345678,arn:aws:controltower:eu-central-1::control/AWS-GR_RESTRICT_ROOT_USER",
+			targetIdentifier:  "arn:aws:organizations::123456789012:ou/o-abcdefghij/ou-abcd-12345678",
+			controlIdentifier: "arn:aws:controltower:eu-central-1::control/AWS-GR_RESTRICT_ROOT_USER",
+			ok:                true,
+		},
+		{id: "arn:aws:controltower:eu-central-1:123456789012:enabledcontrol/ABCDEFGHIJKLMNOP"},
+		{id: "arn:aws:organizations::123456789012:ou/o-abcdefghij/ou-abcd-12345678,"},
+	}
+
+	for _, c := range cases {
+		targetIdentifier, controlIdentifier, ok := parseControlImportId(c.id)
+		if targetIdentifier != c.targetIdentifier || controlIdentifier != c.controlIdentifier || ok != c.ok {
+			t.Errorf("%s: expected (%q, %q, %t), got (%q, %q, %t)", c.id, c.targetIdentifier, c.controlIdentifier, c.ok, targetIdentifier, controlIdentifier, ok)
+		}
+	}
+}
+
+func TestParametersReformattedValue(t *testing.T) {
+	flattened, err := flattenControlParameters([]ctTypes.EnabledControlParameterSummary{
+		{
+			Key:   aws.String("AllowedRegions"),
+			Value: document.NewLazyDocument([]interface{}{"eu-central-1", "eu-west-1"}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error flattening parameters: %v", err)
+	}
+
+	r := resourceControl()
+	d := r.TestResourceData()
+	d.SetId("arn:aws:controltower:eu-central-1:123456789012:enabledcontrol/ABCDEFGHIJKLMNOP")
+	if err := d.Set("control_identifier", "arn:aws:controltower:eu-central-1::control/AWS-GR_REGION_DENY"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Set("target_identifier", "arn:aws:organizations::123456789012:ou/o-abcdefghij/ou-abcd-12345678"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Set("parameters", flattened); err != nil {
+		t.Fatalf("unexpected error setting parameters: %v", err)
+	}
+
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"control_identifier": "arn:aws:controltower:eu-central-1::control/AWS-GR_REGION_DENY",
+		"target_identifier":  "arn:aws:organizations::123456789012:ou/o-abcdefghij/ou-abcd-12345678",
+		"parameters": []interface{}{
+			map[string]interface{}{
+				"key":   "AllowedRegions",
+				"value": "[\n  \"eu-central-1\",\n  \"eu-west-1\"\n]",
+			},
+		},
+	})
+
+	diff, err := r.Diff(context.Background(), d.State(), config, nil)
+	if err != nil {
+		t.Fatalf("unexpected error computing diff: %v", err)
+	}
+	if !diff.Empty() {
+		t.Fatalf("expected no diff for a reformatted value, got %v", diff)
+	}
+}
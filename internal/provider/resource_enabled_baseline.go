@@ -0,0 +1,354 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/controltower"
+	ctTypes "github.com/aws/aws-sdk-go-v2/service/controltower/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceEnabledBaseline() *schema.Resource {
+	return &schema.Resource{
+		Description: "Enables a Control Tower baseline on an account or Organizational Unit.",
+
+		CreateContext: resourceEnabledBaselineCreate,
+		ReadContext:   resourceEnabledBaselineRead,
+		UpdateContext: resourceEnabledBaselineUpdate,
+		DeleteContext: resourceEnabledBaselineDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		CustomizeDiff: resetOnDriftDiff,
+
+		Schema: map[string]*schema.Schema{
+			"baseline_identifier": {
+				Description:  "ARN of the baseline to enable.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateARN,
+			},
+			"baseline_version": {
+				Description: "Version of the baseline, e.g. `4.0`.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"target_identifier": {
+				Description:  "ARN of the account or Organizational Unit on which the baseline is enabled.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateARN,
+			},
+			"parameters": parametersSchema("Parameters of the baseline."),
+			"reset_on_drift": {
+				Description: "If enabled, the plan shows a reset of the baseline whenever it drifted, which resolves the drift during apply.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"tags": {
+				Description: "Key-value map of tags for the enabled baseline.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"arn": {
+				Description: "ARN of the enabled baseline.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"status": {
+				Description: "Enablement status of the baseline, e.g. `SUCCEEDED` or `FAILED`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"last_operation_identifier": {
+				Description: "ID of the last operation on the enabled baseline.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"drift_status": {
+				Description: "Inheritance drift status of the baseline, e.g. `IN_SYNC` or `DRIFTED`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+			Read:   schema.DefaultTimeout(20 * time.Minute),
+			Update: schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+func resourceEnabledBaselineCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutCreate)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	controltowerconn := client.controltowerconn
+
+	targetIdentifier := d.Get("target_identifier").(string)
+
+	parameters, err := expandBaselineParameters(d.Get("parameters").(*schema.Set).List())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	params := &controltower.EnableBaselineInput{
+		BaselineIdentifier: aws.String(d.Get("baseline_identifier").(string)),
+		BaselineVersion:    aws.String(d.Get("baseline_version").(string)),
+		TargetIdentifier:   aws.String(targetIdentifier),
+		Parameters:         parameters,
+	}
+	if tags := toControlTowerTags(d.Get("tags").(map[string]interface{})); len(tags) > 0 {
+		params.Tags = tags
+	}
+
+	err = runBaselineOperation(ctx, client, targetIdentifier, func() (*string, error) {
+		output, err := controltowerconn.EnableBaseline(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		// Set the ID so a failed baseline is disabled again.
+		d.SetId(aws.ToString(output.Arn))
+		return output.OperationIdentifier, nil
+	})
+	if err != nil {
+		return diag.Errorf("error enabling baseline on %s: %v", targetIdentifier, err)
+	}
+
+	return resourceEnabledBaselineRead(ctx, d, m)
+}
+
+func resourceEnabledBaselineRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutRead)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	controltowerconn := client.controltowerconn
+
+	baseline, err := controltowerconn.GetEnabledBaseline(ctx, &controltower.GetEnabledBaselineInput{
+		EnabledBaselineIdentifier: aws.String(d.Id()),
+	})
+	if !d.IsNewResource() {
+		var notFoundErr *ctTypes.ResourceNotFoundException
+		if errors.As(err, &notFoundErr) {
+			d.SetId("")
+			return nil
+		}
+	}
+	if err != nil {
+		return diag.Errorf("error reading enabled baseline %s: %v", d.Id(), err)
+	}
+
+	details := baseline.EnabledBaselineDetails
+	if err := d.Set("arn", details.Arn); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("baseline_identifier", details.BaselineIdentifier); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("baseline_version", details.BaselineVersion); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("target_identifier", details.TargetIdentifier); err != nil {
+		return diag.FromErr(err)
+	}
+
+	parameters, err := flattenBaselineParameters(details.Parameters)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("parameters", parameters); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var status, lastOperationIdentifier, driftStatus string
+	if details.StatusSummary != nil {
+		status = string(details.StatusSummary.Status)
+		lastOperationIdentifier = aws.ToString(details.StatusSummary.LastOperationIdentifier)
+	}
+	if s := details.DriftStatusSummary; s != nil && s.Types != nil && s.Types.Inheritance != nil {
+		driftStatus = string(s.Types.Inheritance.Status)
+	}
+	if err := d.Set("status", status); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("last_operation_identifier", lastOperationIdentifier); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("drift_status", driftStatus); err != nil {
+		return diag.FromErr(err)
+	}
+
+	tags, err := controltowerconn.ListTagsForResource(ctx, &controltower.ListTagsForResourceInput{
+		ResourceArn: aws.String(d.Id()),
+	})
+	if err != nil {
+		return diag.Errorf("error listing tags for resource %s: %v", d.Id(), err)
+	}
+	if err := d.Set("tags", tags.Tags); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceEnabledBaselineUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutUpdate)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	controltowerconn := client.controltowerconn
+
+	targetIdentifier := d.Get("target_identifier").(string)
+
+	if d.HasChanges("baseline_version", "parameters") {
+		parameters, err := expandBaselineParameters(d.Get("parameters").(*schema.Set).List())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		err = runBaselineOperation(ctx, client, targetIdentifier, func() (*string, error) {
+			output, err := controltowerconn.UpdateEnabledBaseline(ctx, &controltower.UpdateEnabledBaselineInput{
+				EnabledBaselineIdentifier: aws.String(d.Id()),
+				BaselineVersion:           aws.String(d.Get("baseline_version").(string)),
+				Parameters:                parameters,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return output.OperationIdentifier, nil
+		})
+		if err != nil {
+			return diag.Errorf("error updating baseline on %s: %v", targetIdentifier, err)
+		}
+	} else if d.HasChange("drift_status") {
+		// The reset was planned by resetOnDriftDiff, which marks drift_status as computed.
+		if err := resetEnabledBaseline(ctx, client, targetIdentifier, d.Id()); err != nil {
+			return diag.Errorf("error resetting baseline on %s: %v", targetIdentifier, err)
+		}
+	}
+
+	if d.HasChange("tags") {
+		o, n := d.GetChange("tags")
+
+		if err := updateControlTowerTags(ctx, controltowerconn, d.Id(), o, n); err != nil {
+			return diag.Errorf("error updating tags of baseline on %s: %v", targetIdentifier, err)
+		}
+	}
+
+	return resourceEnabledBaselineRead(ctx, d, m)
+}
+
+func resourceEnabledBaselineDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutDelete)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	controltowerconn := client.controltowerconn
+
+	targetIdentifier := d.Get("target_identifier").(string)
+
+	err := runBaselineOperation(ctx, client, targetIdentifier, func() (*string, error) {
+		output, err := controltowerconn.DisableBaseline(ctx, &controltower.DisableBaselineInput{
+			EnabledBaselineIdentifier: aws.String(d.Id()),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return output.OperationIdentifier, nil
+	})
+	var notFoundErr *ctTypes.ResourceNotFoundException
+	if err != nil && !errors.As(err, &notFoundErr) {
+		return diag.Errorf("error disabling baseline on %s: %v", targetIdentifier, err)
+	}
+
+	return nil
+}
+
+// resetOnDriftDiff plans a reset of a drifted baseline if reset_on_drift is enabled.
+func resetOnDriftDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.Get("reset_on_drift").(bool) {
+		return nil
+	}
+
+	if d.Get("drift_status").(string) != string(ctTypes.EnabledBaselineDriftStatusDrifted) {
+		return nil
+	}
+
+	// Drift detection may take a while to catch up after the reset, so the resulting status is only known after apply.
+	return d.SetNewComputed("drift_status")
+}
+
+// resetEnabledBaseline resets a drifted baseline and waits until the reset finished.
+func resetEnabledBaseline(ctx context.Context, client *providerClient, name string, enabledBaselineArn string) error {
+	return runBaselineOperation(ctx, client, name, func() (*string, error) {
+		output, err := client.controltowerconn.ResetEnabledBaseline(ctx, &controltower.ResetEnabledBaselineInput{
+			EnabledBaselineIdentifier: aws.String(enabledBaselineArn),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return output.OperationIdentifier, nil
+	})
+}
+
+func expandBaselineParameters(l []interface{}) ([]ctTypes.EnabledBaselineParameter, error) {
+	parameters := make([]ctTypes.EnabledBaselineParameter, 0, len(l))
+
+	for _, v := range l {
+		m := v.(map[string]interface{})
+
+		value, err := decodeParameterValue(m["key"].(string), m["value"].(string))
+		if err != nil {
+			return nil, err
+		}
+
+		parameters = append(parameters, ctTypes.EnabledBaselineParameter{
+			Key:   aws.String(m["key"].(string)),
+			Value: value,
+		})
+	}
+
+	return parameters, nil
+}
+
+func flattenBaselineParameters(parameters []ctTypes.EnabledBaselineParameterSummary) ([]interface{}, error) {
+	result := make([]interface{}, 0, len(parameters))
+
+	for _, parameter := range parameters {
+		value, err := encodeParameterValue(aws.ToString(parameter.Key), parameter.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, map[string]interface{}{
+			"key":   aws.ToString(parameter.Key),
+			"value": value,
+		})
+	}
+
+	return result, nil
+}
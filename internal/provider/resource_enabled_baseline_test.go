@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/controltower/document"
+	ctTypes "github.com/aws/aws-sdk-go-v2/service/controltower/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestBaselineParametersReformattedValue(t *testing.T) {
+	flattened, err := flattenBaselineParameters([]ctTypes.EnabledBaselineParameterSummary{
+		{
+			Key: aws.String("LogRetention"),
+			Value: document.NewLazyDocument(map[string]interface{}{
+				"Days":    365,
+				"Regions": []interface{}{"eu-central-1"},
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error flattening parameters: %v", err)
+	}
+
+	r := resourceEnabledBaseline()
+	d := r.TestResourceData()
+	d.SetId("arn:aws:controltower:eu-central-1:123456789012:enabledbaseline/ABCDEFGHIJKLMNOP")
+	for key, value := range map[string]interface{}{
+		"baseline_identifier": "arn:aws:controltower:eu-central-1::baseline/17BSJV3IGJ2QSGA2",
+		"baseline_version":    "4.0",
+		"target_identifier":   "arn:aws:organizations::123456789012:ou/o-abcdefghij/ou-abcd-12345678",
+		"reset_on_drift":      false,
+		"parameters":          flattened,
+	} {
+		if err := d.Set(key, value); err != nil {
+			t.Fatalf("unexpected error setting %s: %v", key, err)
+		}
+	}
+
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"baseline_identifier": "arn:aws:controltower:eu-central-1::baseline/17BSJV3IGJ2QSGA2",
+		"baseline_version":    "4.0",
+		"target_identifier":   "arn:aws:organizations::123456789012:ou/o-abcdefghij/ou-abcd-12345678",
+		"parameters": []interface{}{
+			map[string]interface{}{
+				"key":   "LogRetention",
+				"value": "{\n  \"Regions\": [\"eu-central-1\"],\n  \"Days\": 365\n}",
+			},
+		},
+	})
+
+	diff, err := r.Diff(context.Background(), d.State(), config, nil)
+	if err != nil {
+		t.Fatalf("unexpected error computing diff: %v", err)
+	}
+	if !diff.Empty() {
+		t.Fatalf("expected no diff for a reformatted value, got %v", diff)
+	}
+}
+
+func TestResetOnDriftDiff(t *testing.T) {
+	r := resourceEnabledBaseline()
+	d := r.TestResourceData()
+	d.SetId("arn:aws:controltower:eu-central-1:123456789012:enabledbaseline/ABCDEFGHIJKLMNOP")
+	for key, value := range map[string]interface{}{
+		"baseline_identifier": "arn:aws:controltower:eu-central-1::baseline/17BSJV3IGJ2QSGA2",
+		"baseline_version":    "4.0",
+		"target_identifier":   "arn:aws:organizations::123456789012:ou/o-abcdefghij/ou-abcd-12345678",
+		"reset_on_drift":      true,
+		"drift_status":        string(ctTypes.EnabledBaselineDriftStatusDrifted),
+	} {
+		if err := d.Set(key, value); err != nil {
+			t.Fatalf("unexpected error setting %s: %v", key, err)
+		}
+	}
+
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"baseline_identifier": "arn:aws:controltower:eu-central-1::baseline/17BSJV3IGJ2QSGA2",
+		"baseline_version":    "4.0",
+		"target_identifier":   "arn:aws:organizations::123456789012:ou/o-abcdefghij/ou-abcd-12345678",
+		"reset_on_drift":      true,
+	})
+
+	diff, err := r.Diff(context.Background(), d.State(), config, nil)
+	if err != nil {
+		t.Fatalf("unexpected error computing diff: %v", err)
+	}
+
+	// The status after the reset depends on drift detection, so it must not be planned as IN_SYNC.
+	attr, ok := diff.Attributes["drift_status"]
+	if !ok || !attr.NewComputed {
+		t.Fatalf("expected drift_status to be computed after the reset, got %v", diff)
+	}
+}
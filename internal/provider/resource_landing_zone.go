@@ -0,0 +1,629 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/controltower"
+	"github.com/aws/aws-sdk-go-v2/service/controltower/document"
+	ctTypes "github.com/aws/aws-sdk-go-v2/service/controltower/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// landingZoneManifestAttributes are the attributes that make up a structured landing zone manifest.
+var landingZoneManifestAttributes = []string{"governed_regions", "organization_structure", "centralized_logging", "security_roles", "access_management_enabled"}
+
+func resourceLandingZone() *schema.Resource {
+	return &schema.Resource{
+		Description: "Manages the Control Tower landing zone.",
+
+		CreateContext: resourceLandingZoneCreate,
+		ReadContext:   resourceLandingZoneRead,
+		UpdateContext: resourceLandingZoneUpdate,
+		DeleteContext: resourceLandingZoneDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"version": {
+				Description: "Version of the landing zone, e.g. `3.3`.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"manifest_json": {
+				Description:      "JSON encoded landing zone manifest. Use this for settings that are not covered by the other attributes. Conflicts with the structured manifest attributes.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ConflictsWith:    landingZoneManifestAttributes,
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: structure.SuppressJsonDiff,
+				StateFunc: func(v interface{}) string {
+					json, _ := structure.NormalizeJsonString(v)
+					return json
+				},
+			},
+			"governed_regions": {
+				Description: "Regions governed by Control Tower.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"organization_structure": {
+				Description: "Names of the Organizational Units created by Control Tower.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"security_ou_name": {
+							Description: "Name of the Organizational Unit of the shared logging and security accounts.",
+							Type:        schema.TypeString,
+							Required:    true,
+						},
+						"sandbox_ou_name": {
+							Description: "Name of the additional Organizational Unit created by Control Tower.",
+							Type:        schema.TypeString,
+							Optional:    true,
+						},
+					},
+				},
+			},
+			"centralized_logging": {
+				Description: "Configuration of the log archive account.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"account_id": {
+							Description:  "ID of the log archive account.",
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateAccountID,
+						},
+						"enabled": {
+							Description: "Whether AWS CloudTrail logs are collected centrally.",
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+						},
+						"logging_bucket_retention_days": {
+							Description:  "Number of days logs are retained in the logging bucket.",
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(1),
+						},
+						"access_logging_bucket_retention_days": {
+							Description:  "Number of days logs are retained in the access logging bucket.",
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(1),
+						},
+						"kms_key_arn": {
+							Description:  "ARN of the KMS key used to encrypt the logs.",
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateARN,
+						},
+					},
+				},
+			},
+			"security_roles": {
+				Description: "Configuration of the audit account.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"account_id": {
+							Description:  "ID of the audit account.",
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateAccountID,
+						},
+					},
+				},
+			},
+			"access_management_enabled": {
+				Description: "Whether Control Tower manages access through IAM Identity Center. Defaults to `true`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+			},
+			"tags": {
+				Description: "Key-value map of tags for the landing zone.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"arn": {
+				Description: "ARN of the landing zone.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"status": {
+				Description: "Status of the landing zone, e.g. `ACTIVE` or `PROCESSING`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"drift_status": {
+				Description: "Drift status of the landing zone, e.g. `IN_SYNC` or `DRIFTED`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"latest_available_version": {
+				Description: "Latest version the landing zone can be updated to.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(120 * time.Minute),
+			Delete: schema.DefaultTimeout(120 * time.Minute),
+			Read:   schema.DefaultTimeout(20 * time.Minute),
+			Update: schema.DefaultTimeout(120 * time.Minute),
+		},
+	}
+}
+
+func resourceLandingZoneCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutCreate)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	controltowerconn := client.controltowerconn
+
+	manifest, err := expandLandingZoneManifest(d, nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	params := &controltower.CreateLandingZoneInput{
+		Manifest: manifest,
+		Version:  aws.String(d.Get("version").(string)),
+	}
+	if tags := toControlTowerTags(d.Get("tags").(map[string]interface{})); len(tags) > 0 {
+		params.Tags = tags
+	}
+
+	var output *controltower.CreateLandingZoneOutput
+	err = retryOnConflict(ctx, "landing zone", func() error {
+		output, err = controltowerconn.CreateLandingZone(ctx, params)
+		return err
+	})
+	if err != nil {
+		return diag.Errorf("error creating landing zone: %v", err)
+	}
+
+	d.SetId(aws.ToString(output.Arn))
+
+	if err := waitForLandingZoneOperation(ctx, output.OperationIdentifier, controltowerconn, defaultPollingConfig); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceLandingZoneRead(ctx, d, m)
+}
+
+func resourceLandingZoneRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutRead)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	controltowerconn := client.controltowerconn
+
+	output, err := controltowerconn.GetLandingZone(ctx, &controltower.GetLandingZoneInput{
+		LandingZoneIdentifier: aws.String(d.Id()),
+	})
+	if !d.IsNewResource() {
+		var notFoundErr *ctTypes.ResourceNotFoundException
+		if errors.As(err, &notFoundErr) {
+			d.SetId("")
+			return nil
+		}
+	}
+	if err != nil {
+		return diag.Errorf("error reading landing zone %s: %v", d.Id(), err)
+	}
+
+	landingZone := output.LandingZone
+	if err := d.Set("arn", landingZone.Arn); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("version", landingZone.Version); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("latest_available_version", landingZone.LatestAvailableVersion); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("status", string(landingZone.Status)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var driftStatus string
+	if landingZone.DriftStatus != nil {
+		driftStatus = string(landingZone.DriftStatus.Status)
+	}
+	if err := d.Set("drift_status", driftStatus); err != nil {
+		return diag.FromErr(err)
+	}
+
+	manifest, err := decodeLandingZoneManifest(landingZone.Manifest)
+	if err != nil {
+		return diag.Errorf("error decoding manifest of landing zone %s: %v", d.Id(), err)
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return diag.Errorf("error encoding manifest of landing zone %s: %v", d.Id(), err)
+	}
+
+	// Keep the manifest in the form it was configured.
+	if d.Get("manifest_json").(string) != "" {
+		if err := d.Set("manifest_json", string(manifestJSON)); err != nil {
+			return diag.FromErr(err)
+		}
+	} else {
+		var structured landingZoneManifest
+		if err := json.Unmarshal(manifestJSON, &structured); err != nil {
+			return diag.Errorf("error decoding manifest of landing zone %s: %v", d.Id(), err)
+		}
+		if err := flattenLandingZoneManifest(d, structured); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	tags, err := controltowerconn.ListTagsForResource(ctx, &controltower.ListTagsForResourceInput{
+		ResourceArn: aws.String(d.Id()),
+	})
+	if err != nil {
+		return diag.Errorf("error listing tags for resource %s: %v", d.Id(), err)
+	}
+	if err := d.Set("tags", tags.Tags); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceLandingZoneUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutUpdate)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	controltowerconn := client.controltowerconn
+
+	if d.HasChangesExcept("tags") {
+		// The structured attributes only cover part of the manifest, the rest is taken from the deployed manifest.
+		var current interface{}
+		if d.Get("manifest_json").(string) == "" {
+			output, err := controltowerconn.GetLandingZone(ctx, &controltower.GetLandingZoneInput{
+				LandingZoneIdentifier: aws.String(d.Id()),
+			})
+			if err != nil {
+				return diag.Errorf("error reading landing zone %s: %v", d.Id(), err)
+			}
+
+			current, err = decodeLandingZoneManifest(output.LandingZone.Manifest)
+			if err != nil {
+				return diag.Errorf("error decoding manifest of landing zone %s: %v", d.Id(), err)
+			}
+		}
+
+		manifest, err := expandLandingZoneManifest(d, current)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		var output *controltower.UpdateLandingZoneOutput
+		err = retryOnConflict(ctx, "landing zone", func() error {
+			output, err = controltowerconn.UpdateLandingZone(ctx, &controltower.UpdateLandingZoneInput{
+				LandingZoneIdentifier: aws.String(d.Id()),
+				Manifest:              manifest,
+				Version:               aws.String(d.Get("version").(string)),
+			})
+			return err
+		})
+		if err != nil {
+			return diag.Errorf("error updating landing zone: %v", err)
+		}
+
+		if err := waitForLandingZoneOperation(ctx, output.OperationIdentifier, controltowerconn, defaultPollingConfig); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChange("tags") {
+		o, n := d.GetChange("tags")
+
+		if err := updateControlTowerTags(ctx, controltowerconn, d.Id(), o, n); err != nil {
+			return diag.Errorf("error updating tags of landing zone: %v", err)
+		}
+	}
+
+	return resourceLandingZoneRead(ctx, d, m)
+}
+
+func resourceLandingZoneDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutDelete)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	controltowerconn := client.controltowerconn
+
+	var output *controltower.DeleteLandingZoneOutput
+	err := retryOnConflict(ctx, "landing zone", func() error {
+		var err error
+		output, err = controltowerconn.DeleteLandingZone(ctx, &controltower.DeleteLandingZoneInput{
+			LandingZoneIdentifier: aws.String(d.Id()),
+		})
+		return err
+	})
+	var notFoundErr *ctTypes.ResourceNotFoundException
+	if errors.As(err, &notFoundErr) {
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("error deleting landing zone: %v", err)
+	}
+
+	if err := waitForLandingZoneOperation(ctx, output.OperationIdentifier, controltowerconn, defaultPollingConfig); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+// landingZoneManifest is the part of the landing zone manifest that is covered by structured attributes.
+type landingZoneManifest struct {
+	GovernedRegions       []string                                  `json:"governedRegions,omitempty"`
+	OrganizationStructure *landingZoneManifestOrganizationStructure `json:"organizationStructure,omitempty"`
+	CentralizedLogging    *landingZoneManifestCentralizedLogging    `json:"centralizedLogging,omitempty"`
+	SecurityRoles         *landingZoneManifestAccount               `json:"securityRoles,omitempty"`
+	AccessManagement      *landingZoneManifestEnabled               `json:"accessManagement,omitempty"`
+}
+
+type landingZoneManifestOrganizationStructure struct {
+	Security *landingZoneManifestName `json:"security,omitempty"`
+	Sandbox  *landingZoneManifestName `json:"sandbox,omitempty"`
+}
+
+type landingZoneManifestName struct {
+	Name string `json:"name"`
+}
+
+type landingZoneManifestCentralizedLogging struct {
+	AccountId      string                                              `json:"accountId"`
+	Enabled        bool                                                `json:"enabled"`
+	Configurations *landingZoneManifestCentralizedLoggingConfiguration `json:"configurations,omitempty"`
+}
+
+type landingZoneManifestCentralizedLoggingConfiguration struct {
+	LoggingBucket       *landingZoneManifestRetention `json:"loggingBucket,omitempty"`
+	AccessLoggingBucket *landingZoneManifestRetention `json:"accessLoggingBucket,omitempty"`
+	KmsKeyArn           string                        `json:"kmsKeyArn,omitempty"`
+}
+
+type landingZoneManifestRetention struct {
+	RetentionDays int `json:"retentionDays"`
+}
+
+type landingZoneManifestAccount struct {
+	AccountId string `json:"accountId"`
+}
+
+type landingZoneManifestEnabled struct {
+	Enabled bool `json:"enabled"`
+}
+
+// landingZoneManifestKeys are the top-level manifest keys covered by landingZoneManifest.
+var landingZoneManifestKeys = []string{"governedRegions", "organizationStructure", "centralizedLogging", "securityRoles", "accessManagement"}
+
+// expandLandingZoneManifest returns the configured manifest, either from manifest_json or the structured attributes.
+// The structured attributes are merged into the current manifest, so that keys they don't cover are kept.
+func expandLandingZoneManifest(d resourceGetter, current interface{}) (document.Interface, error) {
+	manifestJSON := d.Get("manifest_json").(string)
+
+	if manifestJSON == "" {
+		merged, err := mergeLandingZoneManifest(current, expandStructuredLandingZoneManifest(d))
+		if err != nil {
+			return nil, err
+		}
+
+		encoded, err := json.Marshal(merged)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding landing zone manifest: %w", err)
+		}
+		manifestJSON = string(encoded)
+	}
+
+	var manifest interface{}
+	if err := json.Unmarshal([]byte(manifestJSON), &manifest); err != nil {
+		return nil, fmt.Errorf("error decoding landing zone manifest: %w", err)
+	}
+
+	return document.NewLazyDocument(manifest), nil
+}
+
+// mergeLandingZoneManifest replaces the keys of the current manifest that are covered by the structured manifest.
+func mergeLandingZoneManifest(current interface{}, structured landingZoneManifest) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(structured)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding landing zone manifest: %w", err)
+	}
+
+	var modelled map[string]interface{}
+	if err := json.Unmarshal(encoded, &modelled); err != nil {
+		return nil, fmt.Errorf("error decoding landing zone manifest: %w", err)
+	}
+
+	merged := make(map[string]interface{})
+	if m, ok := current.(map[string]interface{}); ok {
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+
+	for _, k := range landingZoneManifestKeys {
+		delete(merged, k)
+		if v, ok := modelled[k]; ok {
+			merged[k] = v
+		}
+	}
+
+	return merged, nil
+}
+
+// decodeLandingZoneManifest decodes the manifest returned by the API, keeping its numbers numeric.
+func decodeLandingZoneManifest(manifest document.Interface) (interface{}, error) {
+	var decoded interface{}
+	if manifest != nil {
+		if err := manifest.UnmarshalSmithyDocument(&decoded); err != nil {
+			return nil, err
+		}
+	}
+
+	return jsonNumbers(decoded), nil
+}
+
+func expandStructuredLandingZoneManifest(d resourceGetter) landingZoneManifest {
+	var manifest landingZoneManifest
+
+	for _, region := range d.Get("governed_regions").(*schema.Set).List() {
+		manifest.GovernedRegions = append(manifest.GovernedRegions, region.(string))
+	}
+
+	if l := d.Get("organization_structure").([]interface{}); len(l) > 0 && l[0] != nil {
+		m := l[0].(map[string]interface{})
+
+		manifest.OrganizationStructure = &landingZoneManifestOrganizationStructure{
+			Security: &landingZoneManifestName{Name: m["security_ou_name"].(string)},
+		}
+		if v := m["sandbox_ou_name"].(string); v != "" {
+			manifest.OrganizationStructure.Sandbox = &landingZoneManifestName{Name: v}
+		}
+	}
+
+	if l := d.Get("centralized_logging").([]interface{}); len(l) > 0 && l[0] != nil {
+		m := l[0].(map[string]interface{})
+
+		manifest.CentralizedLogging = &landingZoneManifestCentralizedLogging{
+			AccountId: m["account_id"].(string),
+			Enabled:   m["enabled"].(bool),
+		}
+
+		configurations := &landingZoneManifestCentralizedLoggingConfiguration{
+			KmsKeyArn: m["kms_key_arn"].(string),
+		}
+		if v := m["logging_bucket_retention_days"].(int); v > 0 {
+			configurations.LoggingBucket = &landingZoneManifestRetention{RetentionDays: v}
+		}
+		if v := m["access_logging_bucket_retention_days"].(int); v > 0 {
+			configurations.AccessLoggingBucket = &landingZoneManifestRetention{RetentionDays: v}
+		}
+		if *configurations != (landingZoneManifestCentralizedLoggingConfiguration{}) {
+			manifest.CentralizedLogging.Configurations = configurations
+		}
+	}
+
+	if l := d.Get("security_roles").([]interface{}); len(l) > 0 && l[0] != nil {
+		manifest.SecurityRoles = &landingZoneManifestAccount{
+			AccountId: l[0].(map[string]interface{})["account_id"].(string),
+		}
+	}
+
+	manifest.AccessManagement = &landingZoneManifestEnabled{Enabled: d.Get("access_management_enabled").(bool)}
+
+	return manifest
+}
+
+func flattenLandingZoneManifest(d *schema.ResourceData, manifest landingZoneManifest) error {
+	if err := d.Set("governed_regions", manifest.GovernedRegions); err != nil {
+		return err
+	}
+
+	var organizationStructure []interface{}
+	if s := manifest.OrganizationStructure; s != nil && s.Security != nil {
+		m := map[string]interface{}{
+			"security_ou_name": s.Security.Name,
+		}
+		if s.Sandbox != nil {
+			m["sandbox_ou_name"] = s.Sandbox.Name
+		}
+		organizationStructure = []interface{}{m}
+	}
+	if err := d.Set("organization_structure", organizationStructure); err != nil {
+		return err
+	}
+
+	var centralizedLogging []interface{}
+	if l := manifest.CentralizedLogging; l != nil {
+		m := map[string]interface{}{
+			"account_id": l.AccountId,
+			"enabled":    l.Enabled,
+		}
+		if c := l.Configurations; c != nil {
+			m["kms_key_arn"] = c.KmsKeyArn
+			if c.LoggingBucket != nil {
+				m["logging_bucket_retention_days"] = c.LoggingBucket.RetentionDays
+			}
+			if c.AccessLoggingBucket != nil {
+				m["access_logging_bucket_retention_days"] = c.AccessLoggingBucket.RetentionDays
+			}
+		}
+		centralizedLogging = []interface{}{m}
+	}
+	if err := d.Set("centralized_logging", centralizedLogging); err != nil {
+		return err
+	}
+
+	var securityRoles []interface{}
+	if manifest.SecurityRoles != nil {
+		securityRoles = []interface{}{map[string]interface{}{"account_id": manifest.SecurityRoles.AccountId}}
+	}
+	if err := d.Set("security_roles", securityRoles); err != nil {
+		return err
+	}
+
+	accessManagementEnabled := manifest.AccessManagement != nil && manifest.AccessManagement.Enabled
+	return d.Set("access_management_enabled", accessManagementEnabled)
+}
+
+// waitForLandingZoneOperation waits until the landing zone operation finished.
+func waitForLandingZoneOperation(ctx context.Context, operationId *string, client *controltower.Client, polling pollingConfig) error {
+	return waitForOperation(ctx, "the landing zone operation", polling, func() (bool, error) {
+		output, err := client.GetLandingZoneOperation(ctx, &controltower.GetLandingZoneOperationInput{
+			OperationIdentifier: operationId,
+		})
+		if err != nil {
+			return false, fmt.Errorf("error reading landing zone operation status: %w", err)
+		}
+
+		switch output.OperationDetails.Status {
+		case ctTypes.LandingZoneOperationStatusSucceeded:
+			return true, nil
+		case ctTypes.LandingZoneOperationStatusFailed:
+			return false, fmt.Errorf("landing zone operation failed: %s", aws.ToString(output.OperationDetails.StatusMessage))
+		}
+
+		return false, nil
+	})
+}
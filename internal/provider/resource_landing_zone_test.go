@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestExpandStructuredLandingZoneManifest(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceLandingZone().Schema, map[string]interface{}{
+		"version":          "3.3",
+		"governed_regions": []interface{}{"eu-central-1"},
+		"organization_structure": []interface{}{
+			map[string]interface{}{
+				"security_ou_name": "Security",
+				"sandbox_ou_name":  "Sandbox",
+			},
+		},
+		"centralized_logging": []interface{}{
+			map[string]interface{}{
+				"account_id":                    "111111111111",
+				"logging_bucket_retention_days": 365,
+			},
+		},
+		"security_roles": []interface{}{
+			map[string]interface{}{
+				"account_id": "222222222222",
+			},
+		},
+	})
+
+	got, err := json.Marshal(expandStructuredLandingZoneManifest(d))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"governedRegions":["eu-central-1"],"organizationStructure":{"security":{"name":"Security"},"sandbox":{"name":"Sandbox"}},"centralizedLogging":{"accountId":"111111111111","enabled":true,"configurations":{"loggingBucket":{"retentionDays":365}}},"securityRoles":{"accountId":"222222222222"},"accessManagement":{"enabled":true}}`
+	if string(got) != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestFlattenLandingZoneManifest(t *testing.T) {
+	var manifest landingZoneManifest
+	err := json.Unmarshal([]byte(`{"governedRegions":["eu-central-1"],"organizationStructure":{"security":{"name":"Security"}},"centralizedLogging":{"accountId":"111111111111","enabled":true,"configurations":{"kmsKeyArn":"arn:aws:kms:eu-central-1:111111111111:key/abc"}},"accessManagement":{"enabled":false},"backup":{"enabled":false}}`), &manifest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d := resourceLandingZone().TestResourceData()
+	if err := flattenLandingZoneManifest(d, manifest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v := d.Get("organization_structure.0.security_ou_name"); v != "Security" {
+		t.Errorf("expected security OU Security, got %v", v)
+	}
+	if v := d.Get("centralized_logging.0.kms_key_arn"); v != "arn:aws:kms:eu-central-1:111111111111:key/abc" {
+		t.Errorf("unexpected KMS key ARN %v", v)
+	}
+	if v := d.Get("access_management_enabled"); v != false {
+		t.Errorf("expected access management to be disabled, got %v", v)
+	}
+	if n := d.Get("governed_regions").(*schema.Set).Len(); n != 1 {
+		t.Errorf("expected 1 governed region, got %d", n)
+	}
+}
+
+func TestMergeLandingZoneManifest(t *testing.T) {
+	var current interface{}
+	err := json.Unmarshal([]byte(`{"governedRegions":["eu-west-1"],"organizationStructure":{"security":{"name":"Security"},"sandbox":{"name":"Sandbox"}},"securityRoles":{"accountId":"222222222222"},"backup":{"enabled":true,"configurations":{"backupAdmin":{"accountId":"333333333333"}}}}`), &current)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	structured := landingZoneManifest{
+		GovernedRegions:       []string{"eu-central-1"},
+		OrganizationStructure: &landingZoneManifestOrganizationStructure{Security: &landingZoneManifestName{Name: "Security"}},
+		AccessManagement:      &landingZoneManifestEnabled{Enabled: true},
+	}
+
+	merged, err := mergeLandingZoneManifest(current, structured)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := json.Marshal(merged)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"accessManagement":{"enabled":true},"backup":{"configurations":{"backupAdmin":{"accountId":"333333333333"}},"enabled":true},"governedRegions":["eu-central-1"],"organizationStructure":{"security":{"name":"Security"}}}`
+	if string(got) != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
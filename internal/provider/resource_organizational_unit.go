@@ -0,0 +1,567 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/controltower"
+	"github.com/aws/aws-sdk-go-v2/service/controltower/document"
+	ctTypes "github.com/aws/aws-sdk-go-v2/service/controltower/types"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgTypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const (
+	controlTowerBaselineName   = "AWSControlTowerBaseline"
+	identityCenterBaselineName = "IdentityCenterBaseline"
+
+	defaultControlTowerBaselineVersion = "4.0"
+)
+
+func resourceOrganizationalUnit() *schema.Resource {
+	return &schema.Resource{
+		Description: "Provides an Organizational Unit that is registered with Control Tower.",
+
+		CreateContext: resourceOrganizationalUnitCreate,
+		ReadContext:   resourceOrganizationalUnitRead,
+		UpdateContext: resourceOrganizationalUnitUpdate,
+		DeleteContext: resourceOrganizationalUnitDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Description:  "Name of the Organizational Unit.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringLenBetween(1, 128),
+			},
+			"parent_id": {
+				Description:   "ID of the root or Organizational Unit under which the Organizational Unit is created. Defaults to the organization root, unless `parent_path` is set. Conflicts with `parent_path`.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"parent_path"},
+				ValidateFunc:  validation.StringMatch(regexp.MustCompile(`^(r-[0-9a-z]{4,32}|ou-[0-9a-z]{4,32}-[a-z0-9]{8,32})$`), "must be the ID of an organization root or OU"),
+			},
+			"parent_path": {
+				Description:   "Path of names of the Organizational Units under which the Organizational Unit is created, starting below the organization root, e.g. `Workloads/Prod`. Conflicts with `parent_id`.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"parent_id"},
+			},
+			"baseline_version": {
+				Description: "Version of the `" + controlTowerBaselineName + "` that is enabled on the Organizational Unit. Defaults to `" + defaultControlTowerBaselineVersion + "`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     defaultControlTowerBaselineVersion,
+			},
+			"identity_center_enabled_baseline_arn": {
+				Description: "ARN of the enabled `" + identityCenterBaselineName + "`, which is passed to the `" + controlTowerBaselineName + "` if Control Tower manages IAM Identity Center. Looked up if not set.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"arn": {
+				Description: "ARN of the Organizational Unit.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"enabled_baseline_arn": {
+				Description: "ARN of the `" + controlTowerBaselineName + "` enabled on the Organizational Unit.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+			Read:   schema.DefaultTimeout(20 * time.Minute),
+			Update: schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+func resourceOrganizationalUnitCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutCreate)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	organizationsconn := client.organizationsconn
+
+	name := d.Get("name").(string)
+
+	parentId, err := expandOrganizationalUnitParentId(ctx, client, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	ou, err := organizationsconn.CreateOrganizationalUnit(ctx, &organizations.CreateOrganizationalUnitInput{
+		Name:     aws.String(name),
+		ParentId: aws.String(parentId),
+	})
+	if err != nil {
+		return diag.Errorf("error creating OU %s: %v", name, err)
+	}
+	client.organizationalUnitChildren.forget(parentId)
+
+	// Set the ID so the OU is deleted in case the registration fails.
+	d.SetId(aws.ToString(ou.OrganizationalUnit.Id))
+
+	enabledBaselineArn, err := enableControlTowerBaseline(ctx, client, d, aws.ToString(ou.OrganizationalUnit.Arn))
+	if err := d.Set("enabled_baseline_arn", enabledBaselineArn); err != nil {
+		return diag.FromErr(err)
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceOrganizationalUnitRead(ctx, d, m)
+}
+
+func resourceOrganizationalUnitRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutRead)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	organizationsconn := client.organizationsconn
+
+	ou, err := client.describeOrganizationalUnit(ctx, d.Id())
+	if !d.IsNewResource() {
+		var notFoundErr *orgTypes.OrganizationalUnitNotFoundException
+		if errors.As(err, &notFoundErr) {
+			d.SetId("")
+			return nil
+		}
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set("name", ou.Name); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("arn", ou.Arn); err != nil {
+		return diag.FromErr(err)
+	}
+
+	parents, err := organizationsconn.ListParents(ctx, &organizations.ListParentsInput{
+		ChildId: aws.String(d.Id()),
+	})
+	if err != nil {
+		return diag.Errorf("error reading parents for %s: %v", d.Id(), err)
+	}
+	if len(parents.Parents) > 0 {
+		parentId := aws.ToString(parents.Parents[0].Id)
+		if err := d.Set("parent_id", parentId); err != nil {
+			return diag.FromErr(err)
+		}
+
+		// Refresh the path, so an OU that was moved outside of Terraform shows a diff.
+		if v, ok := d.GetOk("parent_path"); ok {
+			parentPath, err := organizationalUnitPath(ctx, client, parentId)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			if parentPath != strings.Trim(v.(string), "/") {
+				if err := d.Set("parent_path", parentPath); err != nil {
+					return diag.FromErr(err)
+				}
+			}
+		}
+	}
+
+	baselineArns, err := client.listBaselineArns(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// An OU that is no longer registered shows an empty version, so the baseline is enabled again.
+	enabledBaseline, err := findEnabledBaseline(ctx, client.controltowerconn, baselineArns[controlTowerBaselineName], aws.ToString(ou.Arn))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var enabledBaselineArn, baselineVersion string
+	if enabledBaseline != nil {
+		enabledBaselineArn = aws.ToString(enabledBaseline.Arn)
+		baselineVersion = aws.ToString(enabledBaseline.BaselineVersion)
+	}
+	if err := d.Set("enabled_baseline_arn", enabledBaselineArn); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("baseline_version", baselineVersion); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceOrganizationalUnitUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutUpdate)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	organizationsconn := client.organizationsconn
+	controltowerconn := client.controltowerconn
+
+	name := d.Get("name").(string)
+
+	if d.HasChange("name") {
+		_, err := organizationsconn.UpdateOrganizationalUnit(ctx, &organizations.UpdateOrganizationalUnitInput{
+			OrganizationalUnitId: aws.String(d.Id()),
+			Name:                 aws.String(name),
+		})
+		if err != nil {
+			return diag.Errorf("error renaming OU %s: %v", d.Id(), err)
+		}
+		client.organizationalUnits.forget(d.Id())
+		client.organizationalUnitChildren.forget(d.Get("parent_id").(string))
+	}
+
+	enabledBaselineArn := d.Get("enabled_baseline_arn").(string)
+	switch {
+	case enabledBaselineArn == "":
+		// The OU is not registered (anymore).
+		arn, err := enableControlTowerBaseline(ctx, client, d, d.Get("arn").(string))
+		if err := d.Set("enabled_baseline_arn", arn); err != nil {
+			return diag.FromErr(err)
+		}
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	case d.HasChanges("baseline_version", "identity_center_enabled_baseline_arn"):
+		parameters, err := controlTowerBaselineParameters(ctx, client, d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		err = runBaselineOperation(ctx, client, name, func() (*string, error) {
+			output, err := controltowerconn.UpdateEnabledBaseline(ctx, &controltower.UpdateEnabledBaselineInput{
+				EnabledBaselineIdentifier: aws.String(enabledBaselineArn),
+				BaselineVersion:           aws.String(d.Get("baseline_version").(string)),
+				Parameters:                parameters,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return output.OperationIdentifier, nil
+		})
+		if err != nil {
+			return diag.Errorf("error updating baseline of OU %s: %v", name, err)
+		}
+	case d.HasChange("name"):
+		// Control Tower reports a drift after an OU was renamed, which is resolved by resetting the baseline.
+		err := resetEnabledBaseline(ctx, client, name, enabledBaselineArn)
+		if err != nil {
+			return diag.Errorf("error resetting baseline of OU %s: %v", name, err)
+		}
+	}
+
+	return resourceOrganizationalUnitRead(ctx, d, m)
+}
+
+func resourceOrganizationalUnitDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Create context with configured timeout
+	timeout := d.Timeout(schema.TimeoutDelete)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.(*providerClient)
+
+	organizationsconn := client.organizationsconn
+	controltowerconn := client.controltowerconn
+
+	name := d.Get("name").(string)
+
+	if enabledBaselineArn := d.Get("enabled_baseline_arn").(string); enabledBaselineArn != "" {
+		err := runBaselineOperation(ctx, client, name, func() (*string, error) {
+			output, err := controltowerconn.DisableBaseline(ctx, &controltower.DisableBaselineInput{
+				EnabledBaselineIdentifier: aws.String(enabledBaselineArn),
+			})
+			if err != nil {
+				return nil, err
+			}
+			return output.OperationIdentifier, nil
+		})
+
+		var notFoundErr *ctTypes.ResourceNotFoundException
+		if err != nil && !errors.As(err, &notFoundErr) {
+			return diag.Errorf("error deregistering OU %s: %v", name, err)
+		}
+	}
+
+	_, err := organizationsconn.DeleteOrganizationalUnit(ctx, &organizations.DeleteOrganizationalUnitInput{
+		OrganizationalUnitId: aws.String(d.Id()),
+	})
+	var notFoundErr *orgTypes.OrganizationalUnitNotFoundException
+	if err != nil && !errors.As(err, &notFoundErr) {
+		return diag.Errorf("error deleting OU %s: %v", name, err)
+	}
+	client.organizationalUnits.forget(d.Id())
+	client.organizationalUnitChildren.forget(d.Get("parent_id").(string))
+
+	return nil
+}
+
+// expandOrganizationalUnitParentId returns the ID of the configured parent, which defaults to the organization root.
+func expandOrganizationalUnitParentId(ctx context.Context, client *providerClient, d *schema.ResourceData) (string, error) {
+	if v, ok := d.GetOk("parent_id"); ok {
+		return v.(string), nil
+	}
+
+	rootId, err := findOrganizationRootId(ctx, client.organizationsconn)
+	if err != nil {
+		return "", err
+	}
+
+	parentId := rootId
+	if v, ok := d.GetOk("parent_path"); ok {
+		for _, name := range strings.Split(strings.Trim(v.(string), "/"), "/") {
+			children, err := client.listOrganizationalUnitsForParent(ctx, parentId)
+			if err != nil {
+				return "", err
+			}
+
+			childId := ""
+			for _, child := range children {
+				if aws.ToString(child.Name) == name {
+					childId = aws.ToString(child.Id)
+					break
+				}
+			}
+			if childId == "" {
+				return "", fmt.Errorf("no OU named %q found in parent %s of path %s", name, parentId, v)
+			}
+			parentId = childId
+		}
+	}
+
+	return parentId, nil
+}
+
+// organizationalUnitPath returns the path of names from below the organization root down to the given root or OU.
+func organizationalUnitPath(ctx context.Context, client *providerClient, id string) (string, error) {
+	var names []string
+
+	for strings.HasPrefix(id, "ou-") {
+		ou, err := client.describeOrganizationalUnit(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		names = append([]string{aws.ToString(ou.Name)}, names...)
+
+		parents, err := client.organizationsconn.ListParents(ctx, &organizations.ListParentsInput{
+			ChildId: aws.String(id),
+		})
+		if err != nil {
+			return "", fmt.Errorf("error reading parents for %s: %w", id, err)
+		}
+		if len(parents.Parents) == 0 {
+			break
+		}
+		id = aws.ToString(parents.Parents[0].Id)
+	}
+
+	return strings.Join(names, "/"), nil
+}
+
+func findOrganizationRootId(ctx context.Context, client *organizations.Client) (string, error) {
+	roots, err := client.ListRoots(ctx, &organizations.ListRootsInput{})
+	if err != nil {
+		return "", fmt.Errorf("error listing organization roots: %w", err)
+	}
+	if len(roots.Roots) == 0 {
+		return "", fmt.Errorf("no organization root found")
+	}
+
+	return aws.ToString(roots.Roots[0].Id), nil
+}
+
+// enableControlTowerBaseline registers the OU with Control Tower and returns the ARN of the enabled baseline.
+func enableControlTowerBaseline(ctx context.Context, client *providerClient, d *schema.ResourceData, ouArn string) (string, error) {
+	name := d.Get("name").(string)
+
+	baselineArns, err := client.listBaselineArns(ctx)
+	if err != nil {
+		return "", err
+	}
+	baselineArn, ok := baselineArns[controlTowerBaselineName]
+	if !ok {
+		return "", fmt.Errorf("could not find the %s", controlTowerBaselineName)
+	}
+
+	parameters, err := controlTowerBaselineParameters(ctx, client, d)
+	if err != nil {
+		return "", err
+	}
+
+	var enabledBaselineArn string
+	err = runBaselineOperation(ctx, client, name, func() (*string, error) {
+		output, err := client.controltowerconn.EnableBaseline(ctx, &controltower.EnableBaselineInput{
+			BaselineIdentifier: aws.String(baselineArn),
+			BaselineVersion:    aws.String(d.Get("baseline_version").(string)),
+			TargetIdentifier:   aws.String(ouArn),
+			Parameters:         parameters,
+		})
+		if err != nil {
+			return nil, err
+		}
+		enabledBaselineArn = aws.ToString(output.Arn)
+		return output.OperationIdentifier, nil
+	})
+	if err != nil {
+		return enabledBaselineArn, fmt.Errorf("error registering OU %s: %w", name, err)
+	}
+
+	return enabledBaselineArn, nil
+}
+
+// controlTowerBaselineParameters returns the parameters of the AWSControlTowerBaseline. The enabled
+// IdentityCenterBaseline is required if Control Tower manages IAM Identity Center.
+func controlTowerBaselineParameters(ctx context.Context, client *providerClient, d *schema.ResourceData) ([]ctTypes.EnabledBaselineParameter, error) {
+	identityCenterEnabledBaselineArn := d.Get("identity_center_enabled_baseline_arn").(string)
+
+	if identityCenterEnabledBaselineArn == "" {
+		baselineArns, err := client.listBaselineArns(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if baselineArn, ok := baselineArns[identityCenterBaselineName]; ok {
+			enabledBaseline, err := findEnabledBaseline(ctx, client.controltowerconn, baselineArn, "")
+			if err != nil {
+				return nil, err
+			}
+			if enabledBaseline != nil {
+				identityCenterEnabledBaselineArn = aws.ToString(enabledBaseline.Arn)
+			}
+		}
+
+		if err := d.Set("identity_center_enabled_baseline_arn", identityCenterEnabledBaselineArn); err != nil {
+			return nil, err
+		}
+	}
+
+	if identityCenterEnabledBaselineArn == "" {
+		return nil, nil
+	}
+
+	return []ctTypes.EnabledBaselineParameter{
+		{
+			Key:   aws.String("IdentityCenterEnabledBaselineArn"),
+			Value: document.NewLazyDocument(identityCenterEnabledBaselineArn),
+		},
+	}, nil
+}
+
+// listBaselineArns returns the ARNs of the Control Tower baselines by name.
+func listBaselineArns(ctx context.Context, client *controltower.Client) (map[string]string, error) {
+	arns := map[string]string{}
+
+	paginator := controltower.NewListBaselinesPaginator(client, &controltower.ListBaselinesInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error listing Control Tower baselines: %w", err)
+		}
+
+		for _, baseline := range output.Baselines {
+			arns[aws.ToString(baseline.Name)] = aws.ToString(baseline.Arn)
+		}
+	}
+
+	return arns, nil
+}
+
+// findEnabledBaseline returns the enabled baseline with the given ARN on the target, or on any target
+// if targetArn is empty. It returns nil if the baseline is not enabled.
+func findEnabledBaseline(ctx context.Context, client *controltower.Client, baselineArn string, targetArn string) (*ctTypes.EnabledBaselineSummary, error) {
+	if baselineArn == "" {
+		return nil, nil
+	}
+
+	filter := &ctTypes.EnabledBaselineFilter{
+		BaselineIdentifiers: []string{baselineArn},
+	}
+	if targetArn != "" {
+		filter.TargetIdentifiers = []string{targetArn}
+	}
+
+	paginator := controltower.NewListEnabledBaselinesPaginator(client, &controltower.ListEnabledBaselinesInput{
+		Filter: filter,
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error listing enabled Control Tower baselines: %w", err)
+		}
+
+		if len(output.EnabledBaselines) > 0 {
+			return &output.EnabledBaselines[0], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// runBaselineOperation starts a Control Tower baseline operation while holding the provisioning lock
+// and waits until it finished. name describes the target OU or account in messages.
+func runBaselineOperation(ctx context.Context, client *providerClient, name string, start func() (*string, error)) error {
+	release, err := client.provisioningLock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var operationId *string
+	err = retryOnConflict(ctx, name, func() error {
+		operationId, err = start()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return waitForBaselineOperation(ctx, name, operationId, client.controltowerconn, defaultPollingConfig)
+}
+
+// waitForBaselineOperation waits until the Control Tower baseline operation finished.
+func waitForBaselineOperation(ctx context.Context, name string, operationId *string, client *controltower.Client, polling pollingConfig) error {
+	return waitForOperation(ctx, fmt.Sprintf("the baseline operation on %s", name), polling, func() (bool, error) {
+		output, err := client.GetBaselineOperation(ctx, &controltower.GetBaselineOperationInput{
+			OperationIdentifier: operationId,
+		})
+		if err != nil {
+			return false, fmt.Errorf("error reading status of the baseline operation on %s: %w", name, err)
+		}
+
+		switch output.BaselineOperation.Status {
+		case ctTypes.BaselineOperationStatusSucceeded:
+			return true, nil
+		case ctTypes.BaselineOperationStatusFailed:
+			return false, fmt.Errorf("baseline operation on %s failed: %s", name, aws.ToString(output.BaselineOperation.StatusMessage))
+		}
+
+		return false, nil
+	})
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// ignoreTagsConfig describes tags that are managed outside of Terraform.
+type ignoreTagsConfig struct {
+	keys        map[string]struct{}
+	keyPrefixes []string
+}
+
+// ignored reports whether the tag with the given key should be ignored.
+func (c *ignoreTagsConfig) ignored(key string) bool {
+	if c == nil {
+		return false
+	}
+
+	if _, ok := c.keys[key]; ok {
+		return true
+	}
+
+	for _, prefix := range c.keyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeDefaultTags returns the provider default tags overlaid with the given resource tags.
+func (c *providerClient) mergeDefaultTags(tags map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(c.defaultTags)+len(tags))
+
+	for k, v := range c.defaultTags {
+		result[k] = v
+	}
+	for k, v := range tags {
+		result[k] = v
+	}
+
+	return result
+}
+
+// removeDefaultTags returns the tags that are not covered by an identical provider default tag.
+// Tags that are part of the resource configuration are always kept.
+func (c *providerClient) removeDefaultTags(tags map[string]*string, configured map[string]interface{}) map[string]*string {
+	result := make(map[string]*string, len(tags))
+
+	for k, v := range tags {
+		if defaultValue, ok := c.defaultTags[k]; ok && v != nil && *v == defaultValue {
+			if _, ok := configured[k]; !ok {
+				continue
+			}
+		}
+		result[k] = v
+	}
+
+	return result
+}
+
+// setTagsDiff computes tags_all from the provider default tags and the resource tags,
+// so that changes and drift of the default tags show up in the plan.
+func setTagsDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	client := meta.(*providerClient)
+
+	if !d.GetRawConfig().GetAttr("tags").IsWhollyKnown() {
+		return d.SetNewComputed("tags_all")
+	}
+
+	allTags := client.mergeDefaultTags(d.Get("tags").(map[string]interface{}))
+	for k := range allTags {
+		if client.ignoreTags.ignored(k) {
+			delete(allTags, k)
+		}
+	}
+
+	if reflect.DeepEqual(d.Get("tags_all").(map[string]interface{}), allTags) {
+		return nil
+	}
+
+	return d.SetNew("tags_all", allTags)
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestMergeDefaultTags(t *testing.T) {
+	client := &providerClient{
+		defaultTags: map[string]string{
+			"managed-by":  "terraform",
+			"cost-center": "platform",
+		},
+	}
+
+	got := client.mergeDefaultTags(map[string]interface{}{
+		"cost-center": "engineering",
+		"team":        "cloud",
+	})
+	want := map[string]interface{}{
+		"managed-by":  "terraform",
+		"cost-center": "engineering",
+		"team":        "cloud",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRemoveDefaultTags(t *testing.T) {
+	client := &providerClient{
+		defaultTags: map[string]string{
+			"managed-by":  "terraform",
+			"cost-center": "platform",
+			"owner-team":  "cloud",
+		},
+	}
+
+	tags := map[string]*string{
+		"managed-by":  aws.String("terraform"),
+		"cost-center": aws.String("engineering"),
+		"owner-team":  aws.String("cloud"),
+		"team":        aws.String("cloud"),
+	}
+	configured := map[string]interface{}{
+		"owner-team": "cloud",
+	}
+
+	got := client.removeDefaultTags(tags, configured)
+	want := map[string]*string{
+		"cost-center": aws.String("engineering"),
+		"owner-team":  aws.String("cloud"),
+		"team":        aws.String("cloud"),
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestIgnoreTagsConfig(t *testing.T) {
+	ignoreTags := &ignoreTagsConfig{
+		keys:        map[string]struct{}{"finops-owner": {}},
+		keyPrefixes: []string{"config:", "aws-"},
+	}
+
+	cases := map[string]bool{
+		"finops-owner":   true,
+		"finops-owner-2": false,
+		"config:rule":    true,
+		"aws-backup":     true,
+		"team":           false,
+	}
+
+	for key, want := range cases {
+		if got := ignoreTags.ignored(key); got != want {
+			t.Errorf("ignored(%q) = %t, expected %t", key, got, want)
+		}
+	}
+
+	var unset *ignoreTagsConfig
+	if unset.ignored("finops-owner") {
+		t.Errorf("expected no tags to be ignored without configuration")
+	}
+}
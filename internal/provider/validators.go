@@ -3,6 +3,11 @@ package provider
 import (
 	"fmt"
 	"net/mail"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func validateEmailAddress(v interface{}, k string) (ws []string, errors []error) {
@@ -16,3 +21,43 @@ func validateEmailAddress(v interface{}, k string) (ws []string, errors []error)
 
 	return ws, errors
 }
+
+var accountIdRegexp = regexp.MustCompile(`^\d{12}$`)
+
+func validateAccountID(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if !accountIdRegexp.MatchString(value) {
+		errors = append(errors, fmt.Errorf("%q must be a 12 digit AWS account ID, got: %s", k, value))
+	}
+
+	return ws, errors
+}
+
+func validateARN(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if !arn.IsARN(value) {
+		errors = append(errors, fmt.Errorf("%q must be a valid ARN, got: %s", k, value))
+	}
+
+	return ws, errors
+}
+
+func validateDuration(min time.Duration, max time.Duration) schema.SchemaValidateFunc {
+	return func(v interface{}, k string) (ws []string, errors []error) {
+		value := v.(string)
+
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("%q must be a valid duration like 1h or 15m, parsing failed with: %v", k, err))
+			return ws, errors
+		}
+
+		if duration < min || duration > max {
+			errors = append(errors, fmt.Errorf("%q must be between %s and %s, got: %s", k, min, max, duration))
+		}
+
+		return ws, errors
+	}
+}